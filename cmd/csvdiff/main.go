@@ -0,0 +1,186 @@
+// csvdiff 2つのCSVをキーの列で照合し、追加、削除、変更の行をCSVで出力します
+//
+//	csvdiff -key code[,branch] [-enc auto|utf8|sjis|eucjp] [-out report.csv] [-out-enc utf8bom|utf8|sjis|eucjp] a.csv b.csv
+//
+// 列はそれぞれのヘッダー（"キー値:ラベル"、キー値、ラベル）から作成し、bの列はaの列とキー値かラベルで照合します
+// 終了コードは差分がない場合0、差分がある場合1、エラーの場合2です
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gara-snake/hyutil/hyucsv"
+)
+
+var encodings = map[string]hyucsv.Encoding{
+	"auto":    hyucsv.EncodingAuto,
+	"utf8":    hyucsv.EncodingUTF8,
+	"utf8bom": hyucsv.EncodingUTF8BOM,
+	"sjis":    hyucsv.EncodingShiftJIS,
+	"eucjp":   hyucsv.EncodingEUCJP,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+
+	fs := flag.NewFlagSet("csvdiff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	keys := fs.String("key", "", "行を照合するキーの列（キー値またはラベル、\",\"区切り）")
+	enc := fs.String("enc", "auto", "読み込むCSVの文字コード (auto, utf8, sjis, eucjp)")
+	out := fs.String("out", "", "レポートの出力先。省略した場合は標準出力です")
+	outEnc := fs.String("out-enc", "utf8bom", "レポートの文字コード (utf8bom, utf8, sjis, eucjp)")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() != 2 || *keys == "" {
+		fmt.Fprintln(stderr, "usage: csvdiff -key code a.csv b.csv")
+		fs.PrintDefaults()
+		return 2
+	}
+
+	readEnc, ok := encodings[*enc]
+	if !ok {
+		fmt.Fprintln(stderr, "csvdiff:文字コードが不正です："+*enc)
+		return 2
+	}
+
+	writeEnc, ok := encodings[*outEnc]
+	if !ok || writeEnc == hyucsv.EncodingAuto {
+		fmt.Fprintln(stderr, "csvdiff:文字コードが不正です："+*outEnc)
+		return 2
+	}
+
+	a, err := readFile(fs.Arg(0), nil, readEnc)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	b, err := readFile(fs.Arg(1), a.Fields, readEnc)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	d, err := hyucsv.Diff(a, b, keyFields(a.Fields, strings.Split(*keys, ",")))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	buf, err := d.Report("diff").BufferOptions(hyucsv.WriteOptions{Encoding: writeEnc})
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	if *out == "" {
+
+		if _, err := buf.WriteTo(stdout); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+
+	} else if err := writeFile(*out, buf); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	if d.Equal() {
+		return 0
+	}
+
+	return 1
+}
+
+// writeFile レポートを書き込みます。Closeのエラー（書き込みの失敗）も返却します
+func writeFile(path string, buf *bytes.Buffer) error {
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := buf.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// readFile ヘッダーから列を作成してCSVを読み込みます。baseと一致する列はbaseのキー値とラベルにします
+func readFile(path string, base []hyucsv.CsvField, enc hyucsv.Encoding) (*hyucsv.Csv, error) {
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := hyucsv.NewDecoderOptions(bytes.NewReader(b), nil, hyucsv.ReadOptions{Type: hyucsv.CreateTypeAny, Encoding: enc}).Header()
+	if err != nil {
+		return nil, fmt.Errorf("csvdiff:%s のヘッダーを読み込めません：%w", path, err)
+	}
+
+	fields := make([]hyucsv.CsvField, 0, len(header))
+
+	for _, h := range header {
+
+		key, label, ok := strings.Cut(h, ":")
+		if !ok {
+			label = key
+		}
+
+		f := hyucsv.CsvField{Key: key, Label: label}
+
+		for _, bf := range base {
+			if bf.Key == key || bf.Label == label || bf.Label == key {
+				f = bf
+				break
+			}
+		}
+
+		// 照合は"キー値:ラベル"、キー値、ラベルのいずれか
+		f.Aliases = []string{h}
+		fields = append(fields, f)
+	}
+
+	return hyucsv.Read(filepath.Base(path), fields, bytes.NewReader(b), hyucsv.ReadOptions{
+		Type:     hyucsv.CreateTypeAny,
+		Encoding: enc,
+	})
+}
+
+// keyFields 指定されたキーの列をキー値にします。ラベルで指定された場合はキー値に置き換えます
+func keyFields(fields []hyucsv.CsvField, keys []string) []string {
+
+	ret := make([]string, 0, len(keys))
+
+	for _, k := range keys {
+
+		k = strings.TrimSpace(k)
+
+		for _, f := range fields {
+			if f.Label == k {
+				k = f.Key
+				break
+			}
+		}
+
+		ret = append(ret, k)
+	}
+
+	return ret
+}
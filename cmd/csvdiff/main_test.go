@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/gara-snake/hyutil/hyucsv"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// writeTemp dirにnameのファイルを作成します
+func writeTemp(is is.I, dir string, name string, body string) string {
+
+	path := filepath.Join(dir, name)
+	is.NoErr(os.WriteFile(path, []byte(body), 0o644))
+
+	return path
+}
+
+func TestRunDiff(t *testing.T) {
+
+	is := is.New(t)
+
+	dir := t.TempDir()
+
+	// bのヘッダーはラベルのみ。キーもラベルで指定できる
+	a := writeTemp(is, dir, "a.csv", "code:コード,name:氏名\r\n1,山田\r\n2,鈴木\r\n")
+	b := writeTemp(is, dir, "b.csv", "コード,氏名\r\n1,山田\r\n2,佐藤\r\n3,田中\r\n")
+
+	var stdout, stderr bytes.Buffer
+
+	is.Equal(1, run([]string{"-key", "コード", a, b}, &stdout, &stderr))
+	is.Equal("\ufeffdiff_kind:区分,code:コード,diff_column:列,diff_old:変更前,diff_new:変更後\r\n"+
+		"変更,2,氏名,鈴木,佐藤\r\n"+
+		"追加,3,氏名,,田中\r\n", stdout.String())
+	is.Equal("", stderr.String())
+}
+
+func TestRunEqual(t *testing.T) {
+
+	is := is.New(t)
+
+	dir := t.TempDir()
+
+	sjis, err := japanese.ShiftJIS.NewEncoder().String("コード,氏名\r\n1,山田\r\n2,鈴木\r\n")
+	is.NoErr(err)
+
+	a := writeTemp(is, dir, "a.csv", "\ufeffcode:コード,name:氏名\r\n2,鈴木\r\n1,山田\r\n")
+	b := writeTemp(is, dir, "b.csv", sjis)
+
+	// 文字コードはファイルごとに判定する
+	var stdout, stderr bytes.Buffer
+
+	is.Equal(0, run([]string{"-key", "code", a, b}, &stdout, &stderr))
+	is.Equal("\ufeffdiff_kind:区分,code:コード,diff_column:列,diff_old:変更前,diff_new:変更後\r\n", stdout.String())
+
+	// 文字コードを指定した場合はどちらもその文字コードで読み込む
+	stdout.Reset()
+	is.Equal(0, run([]string{"-key", "コード", "-enc", "sjis", b, b}, &stdout, &stderr))
+	is.Equal("", stderr.String())
+}
+
+func TestRunOut(t *testing.T) {
+
+	is := is.New(t)
+
+	dir := t.TempDir()
+
+	a := writeTemp(is, dir, "a.csv", "code,name\r\n1,山田\r\n2,髙橋\r\n")
+	b := writeTemp(is, dir, "b.csv", "code,name\r\n1,山田\r\n")
+	out := filepath.Join(dir, "report.csv")
+
+	var stdout, stderr bytes.Buffer
+
+	is.Equal(1, run([]string{"-key", "code", "-out", out, "-out-enc", "sjis", a, b}, &stdout, &stderr))
+	is.Equal(0, stdout.Len())
+
+	f, err := os.Open(out)
+	is.NoErr(err)
+	defer f.Close()
+
+	r, err := hyucsv.Read("report", []hyucsv.CsvField{{Key: "diff_kind", Label: "区分"}, {Key: "code", Label: "code"}, {Key: "diff_old", Label: "変更前"}},
+		f, hyucsv.ReadOptions{Encoding: hyucsv.EncodingShiftJIS})
+	is.NoErr(err)
+	is.Equal([]hyucsv.CsvRow{{"diff_kind": "削除", "code": "2", "diff_old": "髙橋"}}, r.Rows)
+}
+
+func TestRunError(t *testing.T) {
+
+	is := is.New(t)
+
+	dir := t.TempDir()
+
+	a := writeTemp(is, dir, "a.csv", "code,name\r\n1,山田\r\n")
+
+	for _, args := range [][]string{
+		{a, a},
+		{"-key", "code", a},
+		{"-unknown", "-key", "code", a, a},
+		{"-key", "code", "-enc", "jis", a, a},
+		{"-key", "code", "-out-enc", "auto", a, a},
+		{"-key", "code", a, filepath.Join(dir, "none.csv")},
+		{"-key", "code", "-out", filepath.Join(dir, "none", "report.csv"), a, a},
+	} {
+		var stdout, stderr bytes.Buffer
+
+		is.Equal(2, run(args, &stdout, &stderr))
+		is.Equal(0, stdout.Len())
+		is.True(0 < stderr.Len())
+	}
+}
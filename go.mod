@@ -5,6 +5,8 @@ go 1.22.5
 require (
 	github.com/cheekybits/is v0.0.0-20150225183255-68e9c0620927
 	github.com/go-sql-driver/mysql v1.8.1
+	golang.org/x/text v0.22.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require filippo.io/edwards25519 v1.1.0 // indirect
@@ -4,14 +4,12 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/csv"
-	"fmt"
 	"io"
 	"log"
 	"reflect"
 	"strings"
 
 	"github.com/gara-snake/hyutil"
-	"github.com/gara-snake/hyutil/hyudb"
 )
 
 const (
@@ -24,12 +22,33 @@ type Csv struct {
 	Name   string
 	Fields []CsvField
 	Rows   []CsvRow
+	// Dialect Bufferで書き込む際の区切り文字などの書式。Readで作成した場合は読み込んだ書式です
+	Dialect Dialect
+	// Lines Rowsの各行の読み込んだファイル上の開始行番号（1始まり）。Readで設定されます
+	// 空行、コメント行、改行を含む値があっても実際の行番号です。ない場合はヘッダーを1行目とした行番号(i+2)を使用します
+	Lines []int
+}
+
+// line i番目の行の行番号
+func (csv *Csv) line(i int) int {
+
+	if i < len(csv.Lines) {
+		return csv.Lines[i]
+	}
+
+	return i + 2
 }
 
 // CsvField Csvファイルのカラム名
 type CsvField struct {
 	Label string
 	Key   string
+	// Aliases KeyとLabel以外に一致させるヘッダー
+	Aliases []string
+	// Required ヘッダーにない場合は読み込みをエラーにします
+	Required bool
+	// Format 値の書式。nilの場合は既定の書式です
+	Format *Format
 }
 
 // String 文字列化
@@ -60,7 +79,7 @@ func addEx(name string) string {
 	return name + extension
 }
 
-// Create Csvデータを作成する
+// Create Csvデータを作成する。区切り文字などの書式はBufferOptionsのWriteOptionsで指定します
 func Create(name string, fields []CsvField, data []interface{}) *Csv {
 
 	csv := &Csv{
@@ -78,7 +97,7 @@ func Create(name string, fields []CsvField, data []interface{}) *Csv {
 			tp = tp.Elem()
 		}
 
-		row := createCsvRow(val, tp)
+		row := createCsvRow(val, tp, fields)
 
 		csv.Rows = append(csv.Rows, row)
 
@@ -102,133 +121,210 @@ func CreateFromFile(name string, fields []CsvField, r io.Reader) *Csv {
 }
 
 // CreateFromFileEx Csvデータを作成する
+// 文字コードはBOMと内容から判定します
 func CreateFromFileEx(name string, fields []CsvField, r io.Reader, opt CreateType) *Csv {
+	return CreateFromFileOptions(name, fields, r, ReadOptions{Type: opt})
+}
 
-	csv := &Csv{
-		Name:   addEx(name),
-		Fields: fields,
-	}
-
-	reader := newCsvReader(r)
-	records, e := reader.ReadAll()
+// CreateFromFileOptions 読み込みの設定（文字コード、区切り文字などの書式を含む）を指定してCsvデータを作成する
+// エラーの場合はReadと異なり、ログに出力して行のないCsvを返却します
+func CreateFromFileOptions(name string, fields []CsvField, r io.Reader, opt ReadOptions) *Csv {
 
-	if e != nil {
-		log.Println(e)
-		return csv
-	}
+	csv, err := Read(name, fields, r, opt)
 
-	if len(records) < 2 {
-		// 行なし
-		return csv
+	if err != nil {
+		log.Println(err)
+		return &Csv{
+			Name:    addEx(name),
+			Fields:  fields,
+			Dialect: opt.Dialect,
+		}
 	}
 
-	header := records[0]
-	detail := records[1:]
-
-	for _, row := range detail {
+	return csv
+}
 
-		csvRow := CsvRow{}
+// Read 読み込みの設定を指定してCsvデータを作成する
+// 必須の列がない場合は*HeaderErrorを返却します
+func Read(name string, fields []CsvField, r io.Reader, opt ReadOptions) (*Csv, error) {
 
-		for _, f := range fields {
-			for i, h := range header {
-				h = strings.Trim(h, " ")
-				h = strings.Trim(h, "　")
+	csv := &Csv{
+		Name:    addEx(name),
+		Fields:  fields,
+		Dialect: opt.Dialect,
+	}
 
-				var headerText string
+	dec := NewDecoderOptions(r, fields, opt)
+	rows := make([]CsvRow, 0)
+	var lines []int
 
-				switch opt {
-				case CreateTypeLabel:
-					headerText = f.Label
-				default:
-					headerText = f.String()
-				}
+	for {
+		row, e := dec.Next()
 
-				if headerText == h {
-					val := strings.Trim(row[i], " ")
-					val = strings.Trim(row[i], "　")
-					csvRow[f.Key] = val
+		if e == io.EOF {
+			break
+		}
 
-					break
-				}
-			}
+		if e != nil {
+			return nil, e
 		}
 
-		csv.Rows = append(csv.Rows, csvRow)
+		rows = append(rows, row)
+		lines = append(lines, dec.Line())
 	}
 
-	return csv
+	if len(rows) > 0 {
+		csv.Rows = rows
+		csv.Lines = lines
+	}
+
+	return csv, nil
 }
 
-func createCsvRow(val reflect.Value, tp reflect.Type) CsvRow {
+func createCsvRow(val reflect.Value, tp reflect.Type, fields []CsvField) CsvRow {
 
 	ret := make(CsvRow)
 
+	appendCsvRow(ret, val, tp, fields, "")
+
+	return ret
+}
+
+// appendCsvRow 構造体のフィールドをrowに設定します
+// 埋め込み構造体のフィールドは同じ階層に、入れ子の構造体のフィールドは "address.city" のキーに展開します
+// 構造体へのポインターは参照先を展開し、nilの場合は展開したフィールドを空にします。valが無効な値の場合はnilの参照先です
+func appendCsvRow(row CsvRow, val reflect.Value, tp reflect.Type, fields []CsvField, prefix string) {
+
 	for i := 0; i < tp.NumField(); i++ {
 
 		field := tp.Field(i)
 
-		key := field.Tag.Get("json")
+		// 埋め込まれた型（sync.Mutexなど）の非公開のフィールドは値を取得できないため除く
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		if field.Tag.Get(tagName) == "-" {
+			continue
+		}
+
+		key := prefix + fieldKey(field)
 
-		if key == "" {
-			key = strings.ToLower(hyutil.CamelToSnake(field.Name))
+		var fval reflect.Value
+		if val.IsValid() {
+			fval = val.Field(i)
 		}
 
-		switch v := val.FieldByName(field.Name).Interface().(type) {
-		case string:
-			ret[key] = v
-		case hyutil.DateTime:
-			ret[key] = v.String()
-		case hyudb.DBID:
-			if v <= 0 {
-				ret[key] = "NULL"
+		ftp := field.Type
+
+		if ftp.Kind() == reflect.Ptr && hyutil.IsNested(ftp.Elem()) && fieldFormat(fields, key) == nil {
+			ftp = ftp.Elem()
+			if fval.IsValid() && !fval.IsNil() {
+				fval = fval.Elem()
 			} else {
-				ret[key] = fmt.Sprint(v)
+				fval = reflect.Value{}
 			}
-		case bool:
-			if v {
-				ret[key] = "1"
-			} else {
-				ret[key] = "0"
+		}
+
+		if hyutil.IsNested(ftp) && fieldFormat(fields, key) == nil {
+
+			if field.Anonymous {
+				appendCsvRow(row, fval, ftp, fields, prefix)
+				continue
 			}
-		case nil:
-			ret[key] = "NULL"
-		default:
-			ret[key] = fmt.Sprint(v)
+
+			appendCsvRow(row, fval, ftp, fields, key+".")
+			continue
 		}
 
-	}
+		if !field.IsExported() {
+			continue
+		}
 
-	return ret
+		if !fval.IsValid() {
+			row[key] = ""
+		} else if f := fieldFormat(fields, key); f != nil {
+			row[key] = f.format(fval)
+		} else {
+			row[key] = formatValue(fval)
+		}
+
+	}
 }
 
 // Buffer 文字列化表現を内包した bytes.Buffer
 func (csv *Csv) Buffer() *bytes.Buffer {
 
+	buf, err := csv.BufferOptions(WriteOptions{Encoding: EncodingUTF8BOM, Dialect: csv.Dialect})
+
+	if err != nil {
+		log.Println(err)
+		return &bytes.Buffer{}
+	}
+
+	return buf
+}
+
+// BufferOptions 書き込みの設定（文字コード、区切り文字などの書式）を指定した文字列化表現を内包した bytes.Buffer
+// csv.Dialectではなくopt.Dialectの書式で書き込みます
+// 文字コードで表現できない文字がある場合は、該当する全ての文字を*UnmappableErrorで返却します
+func (csv *Csv) BufferOptions(opt WriteOptions) (*bytes.Buffer, error) {
+
 	var buf bytes.Buffer
 
-	w := newCsvWriter(io.Writer(&buf), true)
+	enc := NewEncoderOptions(io.Writer(&buf), csv.Fields, opt)
+
+	var unmappable *UnmappableError
 
-	fields := make([]string, 0)
-	for _, f := range csv.Fields {
-		fields = append(fields, f.String())
+	check := func(line int, cols []string) {
+		if err, ok := enc.check(line, cols).(*UnmappableError); ok {
+			if unmappable == nil {
+				unmappable = err
+			} else {
+				unmappable.Chars = append(unmappable.Chars, err.Chars...)
+			}
+		}
 	}
 
-	w.Write(fields)
+	check(1, headerRecord(csv.Fields))
 
-	for _, row := range csv.Rows {
-		cols := make([]string, 0)
+	for i, row := range csv.Rows {
 
-		for _, f := range csv.Fields {
+		cols := make([]string, 0, len(csv.Fields))
 
+		for _, f := range csv.Fields {
 			cols = append(cols, row[f.Key])
+		}
 
+		check(i+2, cols)
+	}
+
+	if unmappable != nil {
+		return nil, unmappable
+	}
+
+	for _, row := range csv.Rows {
+		if err := enc.Encode(row); err != nil {
+			return nil, err
 		}
-		w.Write(cols)
 	}
 
-	w.Flush()
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
 
-	return &buf
+	return &buf, nil
+}
+
+// headerRecord ヘッダー行 "キー値:ラベル"
+func headerRecord(fields []CsvField) []string {
+
+	ret := make([]string, 0, len(fields))
+	for _, f := range fields {
+		ret = append(ret, f.String())
+	}
+
+	return ret
 }
 
 // String 文字列化（BOMは考えない）
@@ -236,6 +332,18 @@ func (csv *Csv) String() string {
 	return csv.Buffer().String()
 }
 
+// StringOptions 書き込みの設定を指定した文字列化
+func (csv *Csv) StringOptions(opt WriteOptions) (string, error) {
+
+	buf, err := csv.BufferOptions(opt)
+
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
 // BOMつき読み取り
 func newCsvReader(r io.Reader) *csv.Reader {
 	br := bufio.NewReader(r)
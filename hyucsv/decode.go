@@ -0,0 +1,198 @@
+package hyucsv
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gara-snake/hyutil"
+)
+
+// CellError 値を変換できなかったセル
+type CellError struct {
+	// Line ファイル上の行番号（1始まり）
+	Line   int
+	Key    string
+	Label  string
+	Value  string
+	Type   string
+	Reason string
+}
+
+func (e *CellError) Error() string {
+	return fmt.Sprintf("%d行目 %s '%s' : %s に変換できません（%s）", e.Line, e.Label, e.Value, e.Type, e.Reason)
+}
+
+// DecodeError 値を変換できなかったセルの一覧
+type DecodeError struct {
+	Cells []*CellError
+}
+
+func (e *DecodeError) Error() string {
+	return "CSVの値が不正です\n" + strings.Join(cellList(e.Cells), "\n")
+}
+
+// cellMessages 1行分のエラーの表示
+func cellMessages(cells []*CellError) string {
+	return strings.Join(cellList(cells), ", ")
+}
+
+func cellList(cells []*CellError) []string {
+
+	list := make([]string, 0, len(cells))
+
+	for _, c := range cells {
+		list = append(list, c.Error())
+	}
+
+	return list
+}
+
+// add 行のエラーを追加します
+func (e *DecodeError) add(cells []*CellError) *DecodeError {
+
+	if len(cells) == 0 {
+		return e
+	}
+
+	if e == nil {
+		e = &DecodeError{}
+	}
+
+	e.Cells = append(e.Cells, cells...)
+
+	return e
+}
+
+// DecodeRow i番目の行を構造体に設定し、変換できなかったセルを返却します
+// 変換できなかったフィールドにはDecodeと同じくゼロ値が設定されます
+func (csv *Csv) DecodeRow(i int, obj interface{}) []*CellError {
+	return decodeRow(obj, csv.Rows[i], csv.line(i), csv.Fields)
+}
+
+// decodeRow hyutil.ObjFillCheckのエラーをCellErrorに変換します
+func decodeRow(obj interface{}, row CsvRow, line int, fields []CsvField) []*CellError {
+
+	buf := make(map[string]string)
+
+	for k, v := range row {
+		buf[k] = v
+	}
+
+	var errs []*CellError
+
+	// CsvFieldのFormatで解析する
+	tp := reflect.TypeOf(obj)
+	if tp.Kind() == reflect.Ptr {
+		tp = tp.Elem()
+	}
+
+	for _, f := range fields {
+
+		val, ok := buf[f.Key]
+
+		if f.Format == nil || !ok || tp.Kind() != reflect.Struct {
+			continue
+		}
+
+		field, ok := fieldByFillKey(tp, f.Key)
+
+		if !ok {
+			continue
+		}
+
+		v, err := f.Format.parse(val, field.Type)
+
+		if err != nil {
+			errs = append(errs, &CellError{
+				Line:   line,
+				Key:    f.Key,
+				Label:  f.Label,
+				Value:  val,
+				Type:   field.Type.String(),
+				Reason: err.Error(),
+			})
+			delete(buf, f.Key)
+			continue
+		}
+
+		buf[f.Key] = v
+	}
+
+	return append(errs, cellErrors(hyutil.ObjFillCheck(obj, buf, false), line, func(key string) (string, string) {
+		return key, fieldLabel(fields, key)
+	})...)
+}
+
+// fieldByFillKey hyutil.ObjFillがkeyを設定するフィールド
+func fieldByFillKey(tp reflect.Type, key string) (reflect.StructField, bool) {
+
+	for i := 0; i < tp.NumField(); i++ {
+
+		f := tp.Field(i)
+		name := fillKey(f)
+
+		if name == key {
+			return f, true
+		}
+
+		if !hyutil.IsNested(f.Type) {
+			continue
+		}
+
+		// 埋め込み構造体は同じキー、入れ子の構造体は "address.city" または "address_city" で探す
+		if f.Anonymous {
+			if ret, ok := fieldByFillKey(f.Type, key); ok {
+				return ret, true
+			}
+			continue
+		}
+
+		for _, sep := range []string{".", "_"} {
+			if rest, ok := strings.CutPrefix(key, name+sep); ok {
+				if ret, ok := fieldByFillKey(f.Type, rest); ok {
+					return ret, true
+				}
+			}
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+func cellErrors(errs []*hyutil.FillError, line int, field func(key string) (string, string)) []*CellError {
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	ret := make([]*CellError, 0, len(errs))
+
+	for _, e := range errs {
+
+		key, label := field(e.Key)
+
+		ret = append(ret, &CellError{
+			Line:   line,
+			Key:    key,
+			Label:  label,
+			Value:  e.Value,
+			Type:   e.Type,
+			Reason: e.Err.Error(),
+		})
+	}
+
+	return ret
+}
+
+// fieldLabel keyのラベル。fieldsにない場合はkeyです
+func fieldLabel(fields []CsvField, key string) string {
+
+	for _, f := range fields {
+		if f.Key == key {
+			return f.Label
+		}
+	}
+
+	return key
+}
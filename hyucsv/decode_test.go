@@ -0,0 +1,61 @@
+package hyucsv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+)
+
+type decodeObj struct {
+	Name string
+	Age  int
+}
+
+func TestDecodeRow(t *testing.T) {
+
+	is := is.New(t)
+
+	c := &Csv{
+		Fields: []CsvField{{Key: "name", Label: "氏名"}, {Key: "age", Label: "年齢"}},
+		Rows: []CsvRow{
+			{"name": "山田", "age": "20"},
+			{"name": "鈴木", "age": "二十"},
+		},
+	}
+
+	var m decodeObj
+	is.Equal(0, len(c.DecodeRow(0, &m)))
+	is.Equal(20, m.Age)
+
+	errs := c.DecodeRow(1, &m)
+	is.Equal([]*CellError{{Line: 3, Key: "age", Label: "年齢", Value: "二十", Type: "int", Reason: "数値ではありません"}}, errs)
+	is.Equal("3行目 年齢 '二十' : int に変換できません（数値ではありません）", errs[0].Error())
+	is.Equal("鈴木", m.Name)
+
+	err := checkDecode[decodeObj](c)
+	is.Equal("CSVの値が不正です\n3行目 年齢 '二十' : int に変換できません（数値ではありません）", err.Error())
+
+	c.Rows = c.Rows[:1]
+	is.NoErr(checkDecode[decodeObj](c))
+}
+
+func TestDecodeRowLine(t *testing.T) {
+
+	is := is.New(t)
+
+	// 空行、コメント行、改行を含む値があってもファイル上の行番号にする
+	src := "name,age\n\n# コメント\n\"山田\n太郎\",20\n鈴木,二十\n"
+
+	c, err := Read("test", []CsvField{{Key: "name", Label: "name"}, {Key: "age", Label: "age"}}, strings.NewReader(src), ReadOptions{
+		Type:    CreateTypeAny,
+		Dialect: Dialect{Comment: '#'},
+	})
+	is.NoErr(err)
+	is.Equal([]int{4, 6}, c.Lines)
+
+	var m decodeObj
+	errs := c.DecodeRow(1, &m)
+	is.Equal(1, len(errs))
+	is.Equal(6, errs[0].Line)
+}
@@ -0,0 +1,163 @@
+package hyucsv
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// Dialect CSVの書式。ゼロ値はカンマ区切り、改行コードCRLF、UTF-8の場合はBOMつきです
+type Dialect struct {
+	// Comma 区切り文字。0の場合はカンマです
+	Comma rune
+	// Comment 読み込み時にこの文字で始まる行を読み飛ばします。0の場合はコメント行はありません
+	Comment rune
+	// LazyQuotes 読み込み時に引用符の誤りを許容します
+	LazyQuotes bool
+	// QuoteAll 書き込み時に全ての値を引用符で囲みます
+	QuoteAll bool
+	// LF 書き込み時の改行コードをLFにします
+	LF bool
+	// NoBOM 書き込み時にBOMをつけません。EncodingUTF8BOMを指定した場合もつけません
+	NoBOM bool
+}
+
+var (
+	// DialectTSV タブ区切り
+	DialectTSV = Dialect{Comma: '\t'}
+	// DialectSemicolon セミコロン区切り
+	DialectSemicolon = Dialect{Comma: ';'}
+	// DialectPipe パイプ区切り
+	DialectPipe = Dialect{Comma: '|'}
+)
+
+var errInvalidDelim = errors.New("hyucsv:区切り文字が不正です")
+
+func (d Dialect) comma() rune {
+	if d.Comma == 0 {
+		return ','
+	}
+	return d.Comma
+}
+
+// validDelim encoding/csvと同じ条件で区切り文字を確認します
+func validDelim(r rune) bool {
+	return r != 0 && r != '"' && r != '\r' && r != '\n' && utf8.ValidRune(r) && r != utf8.RuneError
+}
+
+// apply 読み込みの設定をcsv.Readerに反映します
+func (d Dialect) apply(r *csv.Reader) {
+	r.Comma = d.comma()
+	r.Comment = d.Comment
+	r.LazyQuotes = d.LazyQuotes
+}
+
+// WriteOptions 書き込みの設定
+type WriteOptions struct {
+	// Encoding 文字コード。既定値はBOMつきのUTF-8です
+	Encoding Encoding
+	Dialect  Dialect
+}
+
+// recordWriter 1行ずつの書き込み。csv.Writerと全ての値を引用符で囲むquoteAllWriterがあります
+type recordWriter interface {
+	Write(record []string) error
+	Flush()
+	Error() error
+}
+
+// newRecordWriter 文字コードと書式を指定した書き込み
+func newRecordWriter(w io.Writer, opt WriteOptions) recordWriter {
+
+	enc := opt.Encoding
+
+	if e := enc.encoding(); e != nil {
+		w = transform.NewWriter(w, e.NewEncoder())
+	}
+
+	bom := (enc == EncodingAuto || enc == EncodingUTF8BOM) && !opt.Dialect.NoBOM
+
+	if opt.Dialect.QuoteAll {
+
+		bw := bufio.NewWriter(w)
+		if bom {
+			bw.Write([]byte{0xEF, 0xBB, 0xBF})
+		}
+
+		return &quoteAllWriter{
+			w:     bw,
+			comma: opt.Dialect.comma(),
+			crlf:  !opt.Dialect.LF,
+		}
+	}
+
+	writer := newCsvWriter(w, bom)
+	writer.Comma = opt.Dialect.comma()
+	writer.UseCRLF = !opt.Dialect.LF
+
+	return writer
+}
+
+// quoteAllWriter 全ての値を引用符で囲んで書き込みます。引用符と改行の扱いはcsv.Writerと同じです
+type quoteAllWriter struct {
+	w     *bufio.Writer
+	comma rune
+	crlf  bool
+}
+
+func (q *quoteAllWriter) Write(record []string) error {
+
+	if !validDelim(q.comma) {
+		return errInvalidDelim
+	}
+
+	for i, field := range record {
+
+		if 0 < i {
+			q.w.WriteRune(q.comma)
+		}
+
+		q.w.WriteByte('"')
+
+		for _, r := range field {
+			switch r {
+			case '"':
+				q.w.WriteString(`""`)
+			case '\r':
+				if !q.crlf {
+					q.w.WriteByte('\r')
+				}
+			case '\n':
+				if q.crlf {
+					q.w.WriteString("\r\n")
+				} else {
+					q.w.WriteByte('\n')
+				}
+			default:
+				q.w.WriteRune(r)
+			}
+		}
+
+		q.w.WriteByte('"')
+	}
+
+	if q.crlf {
+		_, err := q.w.WriteString("\r\n")
+		return err
+	}
+
+	return q.w.WriteByte('\n')
+}
+
+func (q *quoteAllWriter) Flush() {
+	q.w.Flush()
+}
+
+func (q *quoteAllWriter) Error() error {
+	_, err := q.w.Write(nil)
+	return err
+}
@@ -0,0 +1,92 @@
+package hyucsv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+)
+
+var dialectFields = []CsvField{
+	{Key: "id", Label: "ID"},
+	{Key: "memo", Label: "備考"},
+}
+
+func TestDialectWrite(t *testing.T) {
+
+	is := is.New(t)
+
+	c := &Csv{
+		Fields: dialectFields,
+		Rows: []CsvRow{
+			{"id": "1", "memo": "a\tb"},
+			{"id": "2", "memo": "改行\nあり \"引用\""},
+		},
+	}
+
+	c.Dialect = Dialect{Comma: '\t', LF: true, NoBOM: true}
+	is.Equal("id:ID\tmemo:備考\n1\t\"a\tb\"\n2\t\"改行\nあり \"\"引用\"\"\"\n", c.String())
+
+	c.Dialect = Dialect{Comma: '|', QuoteAll: true}
+	is.Equal("\ufeff\"id:ID\"|\"memo:備考\"\r\n\"1\"|\"a\tb\"\r\n\"2\"|\"改行\r\nあり \"\"引用\"\"\"\r\n", c.String())
+
+	c.Dialect = Dialect{Comma: '"'}
+	_, err := c.StringOptions(WriteOptions{Encoding: EncodingUTF8, Dialect: c.Dialect})
+	is.Err(err)
+}
+
+func TestDialectRead(t *testing.T) {
+
+	is := is.New(t)
+
+	src := "# 出力日 2020/01/01\nID;備考\n1;a,b\n# 途中のコメント\n2;x \"y\" z\n"
+
+	c, err := Read("test", dialectFields, strings.NewReader(src), ReadOptions{
+		Type:    CreateTypeLabel,
+		Dialect: Dialect{Comma: ';', Comment: '#', LazyQuotes: true},
+	})
+	is.NoErr(err)
+	is.Equal([]CsvRow{{"id": "1", "memo": "a,b"}, {"id": "2", "memo": "x \"y\" z"}}, c.Rows)
+	is.Equal(';', c.Dialect.Comma)
+
+	_, err = Read("test", dialectFields, strings.NewReader(src), ReadOptions{
+		Type:    CreateTypeLabel,
+		Dialect: Dialect{Comma: ';', Comment: '#'},
+	})
+	is.Err(err)
+
+	c, err = Read("test", dialectFields, strings.NewReader("ID\t備考\r\n1\tメモ\r\n"), ReadOptions{Type: CreateTypeLabel, Dialect: DialectTSV})
+	is.NoErr(err)
+	is.Equal("メモ", c.Rows[0]["memo"])
+}
+
+func TestDialectEntryPoints(t *testing.T) {
+
+	is := is.New(t)
+
+	type memo struct {
+		ID   int    `json:"id"`
+		Memo string `json:"memo"`
+	}
+
+	c := Create("test", dialectFields, []interface{}{memo{ID: 1, Memo: "a,b"}})
+	s, err := c.StringOptions(WriteOptions{Encoding: EncodingUTF8, Dialect: DialectTSV})
+	is.NoErr(err)
+	is.Equal("id:ID\tmemo:備考\r\n1\ta,b\r\n", s)
+
+	// Stringは作成したCsvのDialectで書き込む
+	c.Dialect = DialectPipe
+	is.Equal("\ufeffid:ID|memo:備考\r\n1|a,b\r\n", c.String())
+
+	s, err = c.StringOptions(WriteOptions{Encoding: EncodingUTF8, Dialect: DialectSemicolon})
+	is.NoErr(err)
+	is.Equal("id:ID;memo:備考\r\n1;a,b\r\n", s)
+
+	buf, err := c.BufferOptions(WriteOptions{Dialect: Dialect{Comma: '|', LF: true, NoBOM: true}})
+	is.NoErr(err)
+	is.Equal("id:ID|memo:備考\n1|a,b\n", buf.String())
+
+	c = CreateFromFileOptions("test", dialectFields, strings.NewReader(s), ReadOptions{Dialect: DialectSemicolon})
+	is.Equal([]CsvRow{{"id": "1", "memo": "a,b"}}, c.Rows)
+	is.Equal(';', c.Dialect.Comma)
+}
@@ -0,0 +1,290 @@
+package hyucsv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DiffKind 行の差分の種類
+type DiffKind int
+
+const (
+	// DiffAdded bにのみある行
+	DiffAdded DiffKind = iota + 1
+	// DiffRemoved aにのみある行
+	DiffRemoved
+	// DiffChanged キーが一致し、値の異なる列がある行
+	DiffChanged
+)
+
+// String 差分の種類の表記
+func (k DiffKind) String() string {
+
+	switch k {
+	case DiffAdded:
+		return "追加"
+	case DiffRemoved:
+		return "削除"
+	case DiffChanged:
+		return "変更"
+	}
+
+	return ""
+}
+
+// CellDiff 列ごとの変更前と変更後の値
+type CellDiff struct {
+	Key   string
+	Label string
+	Old   string
+	New   string
+}
+
+// RowDiff 行の差分
+type RowDiff struct {
+	Kind DiffKind
+	// Key キーの列の値。keyFieldsの順です
+	Key []string
+	// Old aの行。追加の場合はnilです
+	Old CsvRow
+	// New bの行。削除の場合はnilです
+	New CsvRow
+	// Cells 値の異なる列。変更の場合のみです
+	Cells []CellDiff
+}
+
+// DiffResult 2つのCsvの差分
+type DiffResult struct {
+	// KeyFields 行を照合したキーの列
+	KeyFields []CsvField
+	// Fields 値を比較した列。aとbの両方にある列です
+	Fields []CsvField
+	// Added bにのみある行。bの順です
+	Added []*RowDiff
+	// Removed aにのみある行。aの順です
+	Removed []*RowDiff
+	// Changed 値の異なる列がある行。aの順です
+	Changed []*RowDiff
+}
+
+// Equal 差分がないか
+func (d *DiffResult) Equal() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// keySeparator キーの値を連結する区切り
+const keySeparator = "\x00"
+
+// Diff keyFieldsの列の値で行を照合し、aからbへの追加、削除、変更の行を返却します
+// 値はaとbの両方のFieldsにある列（Keyで一致）のみを文字列のまま比較します
+// aまたはbがnilの場合、キーの列がない場合、または同じキーの行が複数ある場合はエラーを返却します
+func Diff(a, b *Csv, keyFields []string) (*DiffResult, error) {
+
+	if a == nil || b == nil {
+		return nil, errors.New("hyucsv:比較するCsvがありません")
+	}
+
+	if len(keyFields) == 0 {
+		return nil, errors.New("hyucsv:キーの列を指定してください")
+	}
+
+	ret := &DiffResult{}
+
+	for _, k := range keyFields {
+
+		f, ok := diffField(a.Fields, k)
+
+		if !ok {
+			return nil, fmt.Errorf("hyucsv:%s にキーの列がありません：%s", a.Name, k)
+		}
+
+		if _, ok := diffField(b.Fields, k); !ok {
+			return nil, fmt.Errorf("hyucsv:%s にキーの列がありません：%s", b.Name, k)
+		}
+
+		ret.KeyFields = append(ret.KeyFields, f)
+	}
+
+	for _, f := range a.Fields {
+
+		if _, ok := diffField(b.Fields, f.Key); !ok || isKeyField(keyFields, f.Key) {
+			continue
+		}
+
+		ret.Fields = append(ret.Fields, f)
+	}
+
+	rowsA, err := indexRows(a, keyFields)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rowsB, err := indexRows(b, keyFields)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range a.Rows {
+
+		key := rowKey(row, keyFields)
+		newRow, ok := rowsB[strings.Join(key, keySeparator)]
+
+		if !ok {
+			ret.Removed = append(ret.Removed, &RowDiff{Kind: DiffRemoved, Key: key, Old: row})
+			continue
+		}
+
+		var cells []CellDiff
+
+		for _, f := range ret.Fields {
+			if row[f.Key] != newRow[f.Key] {
+				cells = append(cells, CellDiff{Key: f.Key, Label: f.Label, Old: row[f.Key], New: newRow[f.Key]})
+			}
+		}
+
+		if 0 < len(cells) {
+			ret.Changed = append(ret.Changed, &RowDiff{Kind: DiffChanged, Key: key, Old: row, New: newRow, Cells: cells})
+		}
+	}
+
+	for _, row := range b.Rows {
+
+		key := rowKey(row, keyFields)
+
+		if _, ok := rowsA[strings.Join(key, keySeparator)]; !ok {
+			ret.Added = append(ret.Added, &RowDiff{Kind: DiffAdded, Key: key, New: row})
+		}
+	}
+
+	return ret, nil
+}
+
+func diffField(fields []CsvField, key string) (CsvField, bool) {
+
+	for _, f := range fields {
+		if f.Key == key {
+			return f, true
+		}
+	}
+
+	return CsvField{}, false
+}
+
+func isKeyField(keyFields []string, key string) bool {
+
+	for _, k := range keyFields {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func rowKey(row CsvRow, keyFields []string) []string {
+
+	ret := make([]string, len(keyFields))
+
+	for i, k := range keyFields {
+		ret[i] = row[k]
+	}
+
+	return ret
+}
+
+// indexRows キーの値ごとの行。同じキーの行が複数ある場合はエラーです
+func indexRows(c *Csv, keyFields []string) (map[string]CsvRow, error) {
+
+	ret := make(map[string]CsvRow, len(c.Rows))
+
+	for i, row := range c.Rows {
+
+		key := rowKey(row, keyFields)
+		k := strings.Join(key, keySeparator)
+
+		if _, ok := ret[k]; ok {
+			return nil, fmt.Errorf("hyucsv:%s の%d件目のキーが重複しています：%s", c.Name, i+1, strings.Join(key, ","))
+		}
+
+		ret[k] = row
+	}
+
+	return ret, nil
+}
+
+// 差分のレポートの列のキー
+const (
+	diffKindKey   = "diff_kind"
+	diffColumnKey = "diff_column"
+	diffOldKey    = "diff_old"
+	diffNewKey    = "diff_new"
+)
+
+// Report 差分をCsvにします。列は区分、キーの列、列、変更前、変更後です
+// 変更の行は値の異なる列ごとに1行、削除の行は列ごとに変更前、追加の行は列ごとに変更後を設定した1行で、変更、削除、追加の順に並びます
+// 比較した列(Fields)がない場合、追加と削除の行はキーの列のみの1行です
+func (d *DiffResult) Report(name string) *Csv {
+
+	fields := []CsvField{{Key: diffKindKey, Label: "区分"}}
+	fields = append(fields, d.KeyFields...)
+	fields = append(fields,
+		CsvField{Key: diffColumnKey, Label: "列"},
+		CsvField{Key: diffOldKey, Label: "変更前"},
+		CsvField{Key: diffNewKey, Label: "変更後"},
+	)
+
+	c := &Csv{
+		Name:   addEx(name),
+		Fields: fields,
+	}
+
+	newRow := func(rd *RowDiff) CsvRow {
+
+		row := CsvRow{diffKindKey: rd.Kind.String()}
+
+		for i, f := range d.KeyFields {
+			row[f.Key] = rd.Key[i]
+		}
+
+		return row
+	}
+
+	for _, rd := range d.Changed {
+		for _, cell := range rd.Cells {
+			row := newRow(rd)
+			row[diffColumnKey] = cell.Label
+			row[diffOldKey] = cell.Old
+			row[diffNewKey] = cell.New
+			c.Rows = append(c.Rows, row)
+		}
+	}
+
+	// 追加と削除の行は、行の値を列ごとに変更前または変更後に設定する
+	rowValues := func(rd *RowDiff, src CsvRow, valueKey string) {
+
+		if len(d.Fields) == 0 {
+			c.Rows = append(c.Rows, newRow(rd))
+			return
+		}
+
+		for _, f := range d.Fields {
+			row := newRow(rd)
+			row[diffColumnKey] = f.Label
+			row[valueKey] = src[f.Key]
+			c.Rows = append(c.Rows, row)
+		}
+	}
+
+	for _, rd := range d.Removed {
+		rowValues(rd, rd.Old, diffOldKey)
+	}
+
+	for _, rd := range d.Added {
+		rowValues(rd, rd.New, diffNewKey)
+	}
+
+	return c
+}
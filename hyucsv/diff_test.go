@@ -0,0 +1,73 @@
+package hyucsv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+)
+
+func TestDiff(t *testing.T) {
+
+	is := is.New(t)
+
+	a := &Csv{
+		Name:   "a.csv",
+		Fields: []CsvField{{Key: "code", Label: "コード"}, {Key: "name", Label: "名称"}, {Key: "price", Label: "単価"}, {Key: "memo", Label: "備考"}},
+		Rows: []CsvRow{
+			{"code": "A01", "name": "りんご", "price": "100", "memo": "x"},
+			{"code": "A02", "name": "みかん", "price": "80"},
+			{"code": "A03", "name": "ぶどう", "price": "300"},
+		},
+	}
+
+	b := &Csv{
+		Name:   "b.csv",
+		Fields: []CsvField{{Key: "price", Label: "単価"}, {Key: "code", Label: "コード"}, {Key: "name", Label: "名称"}},
+		Rows: []CsvRow{
+			{"code": "A04", "name": "もも", "price": "250"},
+			{"code": "A03", "name": "ぶどう", "price": "300"},
+			{"code": "A01", "name": "リンゴ", "price": "120"},
+		},
+	}
+
+	d, err := Diff(a, b, []string{"code"})
+	is.NoErr(err)
+	is.False(d.Equal())
+	is.Equal(2, len(d.Fields))
+	is.Equal([]CellDiff{{Key: "name", Label: "名称", Old: "りんご", New: "リンゴ"}, {Key: "price", Label: "単価", Old: "100", New: "120"}}, d.Changed[0].Cells)
+	is.Equal([]string{"A02"}, d.Removed[0].Key)
+	is.Equal(DiffAdded, d.Added[0].Kind)
+	is.Equal("もも", d.Added[0].New["name"])
+
+	s, err := d.Report("diff").StringOptions(WriteOptions{Encoding: EncodingUTF8})
+	is.NoErr(err)
+	is.Equal("diff_kind:区分,code:コード,diff_column:列,diff_old:変更前,diff_new:変更後\r\n"+
+		"変更,A01,名称,りんご,リンゴ\r\n"+
+		"変更,A01,単価,100,120\r\n"+
+		"削除,A02,名称,みかん,\r\n"+
+		"削除,A02,単価,80,\r\n"+
+		"追加,A04,名称,,もも\r\n"+
+		"追加,A04,単価,,250\r\n", s)
+
+	// 比較する列がない場合はキーの列のみ
+	d, err = Diff(a, b, []string{"code", "name", "price"})
+	is.NoErr(err)
+	s, err = d.Report("diff").StringOptions(WriteOptions{Encoding: EncodingUTF8})
+	is.NoErr(err)
+	is.True(strings.Contains(s, "削除,A02,みかん,80,,,\r\n"))
+
+	d, err = Diff(a, a, []string{"code", "name"})
+	is.NoErr(err)
+	is.True(d.Equal())
+
+	_, err = Diff(a, nil, []string{"code"})
+	is.Equal("hyucsv:比較するCsvがありません", err.Error())
+
+	_, err = Diff(a, b, []string{"memo"})
+	is.Equal("hyucsv:b.csv にキーの列がありません：memo", err.Error())
+
+	b.Rows = append(b.Rows, CsvRow{"code": "A04"})
+	_, err = Diff(a, b, []string{"code"})
+	is.Equal("hyucsv:b.csv の4件目のキーが重複しています：A04", err.Error())
+}
@@ -0,0 +1,201 @@
+package hyucsv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+)
+
+// Encoding CSVファイルの文字コード
+type Encoding int
+
+const (
+	// EncodingAuto 読み込み時にBOMと内容から判定します。書き込み時はEncodingUTF8BOMと同じです
+	EncodingAuto Encoding = iota
+	// EncodingUTF8 BOMなしのUTF-8。読み込み時はBOMがあれば除去します
+	EncodingUTF8
+	// EncodingUTF8BOM BOMつきのUTF-8
+	EncodingUTF8BOM
+	// EncodingShiftJIS Shift_JIS（Windowsの拡張文字を含むCP932）
+	EncodingShiftJIS
+	// EncodingEUCJP EUC-JP
+	EncodingEUCJP
+)
+
+// String 文字列化
+func (e Encoding) String() string {
+	switch e {
+	case EncodingAuto:
+		return "自動判定"
+	case EncodingUTF8:
+		return "UTF-8"
+	case EncodingUTF8BOM:
+		return "UTF-8(BOM)"
+	case EncodingShiftJIS:
+		return "Shift_JIS"
+	case EncodingEUCJP:
+		return "EUC-JP"
+	}
+	return fmt.Sprintf("Encoding(%d)", int(e))
+}
+
+// encoding UTF-8以外の場合に変換に使用するencoding.Encoding。UTF-8の場合はnilです
+func (e Encoding) encoding() encoding.Encoding {
+	switch e {
+	case EncodingShiftJIS:
+		return japanese.ShiftJIS
+	case EncodingEUCJP:
+		return japanese.EUCJP
+	}
+	return nil
+}
+
+// Unmappable 指定の文字コードで表現できない文字
+type Unmappable struct {
+	// Line ヘッダーを1行目とした行番号
+	Line int
+	Key  string
+	Char rune
+}
+
+// UnmappableError 書き込み時に指定の文字コードで表現できない文字があった場合のエラー
+type UnmappableError struct {
+	Encoding Encoding
+	Chars    []Unmappable
+}
+
+func (e *UnmappableError) Error() string {
+
+	list := make([]string, 0, len(e.Chars))
+
+	for _, c := range e.Chars {
+		list = append(list, fmt.Sprintf("%d行目 %s '%c'(U+%04X)", c.Line, c.Key, c.Char, c.Char))
+	}
+
+	return e.Encoding.String() + "に変換できない文字があります : " + strings.Join(list, ", ")
+}
+
+// detectPeek 文字コードの判定に使用する先頭のバイト数
+const detectPeek = 64 * 1024
+
+// detectEncoding 先頭のバイト列から文字コードを判定します
+func detectEncoding(bs []byte) Encoding {
+
+	if bytes.HasPrefix(bs, []byte{0xEF, 0xBB, 0xBF}) {
+		return EncodingUTF8BOM
+	}
+
+	// 読み込みの区切りで途中になった末尾の文字は判定に含めない
+	for i := len(bs) - 1; 0 <= i && len(bs)-utf8.UTFMax <= i; i-- {
+		if utf8.RuneStart(bs[i]) {
+			if !utf8.FullRune(bs[i:]) {
+				bs = bs[:i]
+			}
+			break
+		}
+	}
+
+	if utf8.Valid(bs) {
+		return EncodingUTF8
+	}
+
+	// 0x81-0x9F はEUC-JPでは0x8E,0x8F以外に現れない
+	for _, b := range bs {
+		if 0x81 <= b && b <= 0x9F && b != 0x8E && b != 0x8F {
+			return EncodingShiftJIS
+		}
+	}
+
+	sjis := decodeScore(bs, japanese.ShiftJIS)
+	euc := decodeScore(bs, japanese.EUCJP)
+
+	if euc < sjis {
+		return EncodingEUCJP
+	}
+
+	return EncodingShiftJIS
+}
+
+// decodeScore 不正なバイト列と半角カナの数。EUC-JPをShift_JISとして読むと半角カナが多くなる
+func decodeScore(bs []byte, enc encoding.Encoding) int {
+
+	s, _, err := transform.Bytes(enc.NewDecoder(), bs)
+
+	if err != nil {
+		return len(bs)
+	}
+
+	score := 0
+
+	for _, r := range string(s) {
+		if r == utf8.RuneError {
+			score += 10
+		} else if 0xFF61 <= r && r <= 0xFF9F {
+			score++
+		}
+	}
+
+	return score
+}
+
+// newCsvReaderEncoding 文字コードを指定した読み取り。UTF-8に変換して読み込みます
+func newCsvReaderEncoding(r io.Reader, enc Encoding) *csv.Reader {
+
+	if enc == EncodingAuto {
+		br := bufio.NewReaderSize(r, detectPeek)
+		bs, _ := br.Peek(detectPeek)
+		enc = detectEncoding(bs)
+		r = br
+	}
+
+	if e := enc.encoding(); e != nil {
+		return csv.NewReader(transform.NewReader(r, e.NewDecoder()))
+	}
+
+	return newCsvReader(r)
+}
+
+// unmappable 指定の文字コードで表現できない文字を返却します
+type unmappable struct {
+	encoder *encoding.Encoder
+}
+
+func newUnmappable(enc Encoding) *unmappable {
+
+	e := enc.encoding()
+
+	if e == nil {
+		return nil
+	}
+
+	return &unmappable{encoder: e.NewEncoder()}
+}
+
+func (u *unmappable) chars(s string) []rune {
+
+	if u == nil {
+		return nil
+	}
+
+	if _, err := u.encoder.String(s); err == nil {
+		return nil
+	}
+
+	ret := make([]rune, 0)
+
+	for _, r := range s {
+		if _, err := u.encoder.String(string(r)); err != nil {
+			ret = append(ret, r)
+		}
+	}
+
+	return ret
+}
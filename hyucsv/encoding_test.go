@@ -0,0 +1,83 @@
+package hyucsv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+)
+
+var encodingFields = []CsvField{
+	{Key: "name", Label: "氏名"},
+	{Key: "kana", Label: "カナ"},
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+
+	is := is.New(t)
+
+	c := &Csv{
+		Name:   "test.csv",
+		Fields: encodingFields,
+		Rows: []CsvRow{
+			{"name": "山田 太郎", "kana": "ﾔﾏﾀﾞ ﾀﾛｳ"},
+			{"name": "髙橋 ①", "kana": "タカハシ"},
+		},
+	}
+
+	for _, enc := range []Encoding{EncodingUTF8, EncodingUTF8BOM, EncodingShiftJIS, EncodingEUCJP} {
+
+		if enc == EncodingEUCJP {
+			// IBM拡張文字、丸数字はEUC-JPにない
+			c.Rows[1]["name"] = "高橋 一"
+		}
+
+		buf, err := c.BufferOptions(WriteOptions{Encoding: enc})
+		is.NoErr(err)
+
+		is.Equal(enc == EncodingUTF8BOM, bytes.HasPrefix(buf.Bytes(), []byte{0xEF, 0xBB, 0xBF}))
+
+		for _, read := range []Encoding{enc, EncodingAuto} {
+			r := CreateFromFileOptions("test", encodingFields, bytes.NewReader(buf.Bytes()), ReadOptions{Encoding: read})
+			is.Equal(c.Rows, r.Rows)
+		}
+	}
+}
+
+func TestEncodingUnmappable(t *testing.T) {
+
+	is := is.New(t)
+
+	c := &Csv{
+		Fields: encodingFields,
+		Rows: []CsvRow{
+			{"name": "山田 太郎", "kana": "ヤマダ"},
+			{"name": "𠮷田 😀", "kana": "ヨシダ"},
+		},
+	}
+
+	_, err := c.StringOptions(WriteOptions{Encoding: EncodingShiftJIS})
+
+	u, ok := err.(*UnmappableError)
+	is.True(ok)
+	is.Equal([]Unmappable{{Line: 3, Key: "name", Char: '𠮷'}, {Line: 3, Key: "name", Char: '😀'}}, u.Chars)
+	is.True(strings.Contains(err.Error(), "3行目 name '𠮷'(U+20BB7)"))
+
+	_, err = c.StringOptions(WriteOptions{Encoding: EncodingUTF8})
+	is.NoErr(err)
+}
+
+func TestDetectEncoding(t *testing.T) {
+
+	is := is.New(t)
+
+	is.Equal(EncodingUTF8BOM, detectEncoding([]byte("\xEF\xBB\xBFあ")))
+	is.Equal(EncodingUTF8, detectEncoding([]byte("abc,def")))
+	// 末尾で途切れたUTF-8
+	is.Equal(EncodingUTF8, detectEncoding([]byte("あい")[:5]))
+	// "あいう" Shift_JIS
+	is.Equal(EncodingShiftJIS, detectEncoding([]byte{0x82, 0xA0, 0x82, 0xA2, 0x82, 0xA4}))
+	// "漢字" EUC-JP
+	is.Equal(EncodingEUCJP, detectEncoding([]byte{0xB4, 0xC1, 0xBB, 0xFA}))
+}
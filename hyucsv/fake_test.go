@@ -0,0 +1,18 @@
+package hyucsv
+
+import (
+	"github.com/gara-snake/hyutil/hyudb"
+	"github.com/gara-snake/hyutil/internal/hyudbtest"
+)
+
+var fake = &hyudbtest.Connector{}
+
+func init() {
+	hyudbtest.Register("hyucsv_fake", fake)
+}
+
+// newFakeDB fakeに接続するDB
+func newFakeDB() *hyudb.DB {
+	fake.Reset()
+	return hyudb.New("hyucsv_fake", "")
+}
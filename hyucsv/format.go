@@ -0,0 +1,291 @@
+package hyucsv
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gara-snake/hyutil"
+	"github.com/gara-snake/hyutil/hyudb"
+)
+
+// Format 値の書式。CsvField.Formatまたはcsvタグで指定します。読み込み時は同じ書式で解析します
+// 指定しない場合は、真偽値は"1"/"0"、0以下のDBIDとnilは"NULL"、日付はhyutil.DateTimeFormatです
+//
+//	Valid  bool            `csv:"valid,label=状態,bool=有効/無効"`
+//	Price  float64         `csv:"price,label=金額,thousands,decimals=2"`
+//	Date   hyutil.DateTime `csv:"date,label=日付,date=2006/01/02,null=-"`
+//	Rate   float64         `csv:"rate,label=率,format=%.2f"`
+type Format struct {
+	// Date hyutil.DateTimeの書式。空の場合はhyutil.DateTimeFormatです
+	Date string
+	// True 真偽値のtrueの表記。空の場合は"1"です
+	True string
+	// False 真偽値のfalseの表記。空の場合は"0"です
+	False string
+	// Thousands 数値を3桁ごとに","で区切ります
+	Thousands bool
+	// Decimals 小数点以下の桁数。0の場合は整数に丸め、nilの場合は値のままです
+	Decimals *int
+	// Null 0以下のDBID、ゼロ値のhyutil.DateTime、nilの表記です。nilの場合はFormatを指定しない場合と同じです
+	Null *string
+	// Printf hyutil.DateTime以外の値をfmt.Sprintfで文字列にする書式です。読み込み時は値のまま解析します
+	Printf string
+}
+
+var (
+	dateTimeType = reflect.TypeOf(hyutil.DateTime{})
+	dbidType     = reflect.TypeOf(hyudb.DBID(0))
+)
+
+// isNull Nullで表記する値か
+func isNull(v reflect.Value) bool {
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	switch v.Type() {
+	case dbidType:
+		return v.Int() <= 0
+	case dateTimeType:
+		return v.Interface().(hyutil.DateTime) == hyutil.DateTimeZero
+	}
+
+	return false
+}
+
+// format 値を書式に従って文字列にします
+func (f *Format) format(v reflect.Value) string {
+
+	if isNull(v) {
+		if f.Null == nil {
+			return formatValue(v)
+		}
+		return *f.Null
+	}
+
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	if v.Type() == dateTimeType {
+		layout := f.Date
+		if layout == "" {
+			layout = hyutil.DateTimeFormat
+		}
+		return v.Interface().(hyutil.DateTime).Format(layout)
+	}
+
+	if f.Printf != "" {
+		return fmt.Sprintf(f.Printf, v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return orDefault(f.True, "1")
+		}
+		return orDefault(f.False, "0")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return f.number(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return f.number(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		prec := -1
+		if f.Decimals != nil {
+			prec = *f.Decimals
+		}
+		return f.number(strconv.FormatFloat(v.Float(), 'f', prec, v.Type().Bits()))
+	case reflect.String:
+		return v.String()
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+// formatString DBから取得した文字列を書式に従って文字列にします。NULLは空文字で渡します
+// 型がわからないため、日付、"1"/"0"、数値として解析できる値にのみ書式を適用します
+func (f *Format) formatString(s string) string {
+
+	if s == "" {
+		if f.Null == nil {
+			return s
+		}
+		return *f.Null
+	}
+
+	if f.Date != "" {
+		if d := hyutil.DatetimeParse(s); d != hyutil.DateTimeZero {
+			return d.Format(f.Date)
+		}
+	}
+
+	if f.True != "" || f.False != "" {
+		switch s {
+		case "1":
+			return orDefault(f.True, "1")
+		case "0":
+			return orDefault(f.False, "0")
+		}
+	}
+
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if f.Printf != "" {
+			return fmt.Sprintf(f.Printf, i)
+		}
+		return f.number(s)
+	}
+
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		if f.Printf != "" {
+			return fmt.Sprintf(f.Printf, n)
+		}
+		if f.Decimals != nil {
+			s = strconv.FormatFloat(n, 'f', *f.Decimals, 64)
+		}
+		return f.number(s)
+	}
+
+	if f.Printf != "" {
+		return fmt.Sprintf(f.Printf, s)
+	}
+
+	return s
+}
+
+// number Thousandsの場合に整数部を3桁ごとに区切ります
+func (f *Format) number(s string) string {
+
+	if !f.Thousands {
+		return s
+	}
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	intPart, frac, hasFrac := strings.Cut(s, ".")
+
+	var b strings.Builder
+
+	for i, c := range intPart {
+		if 0 < i && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+
+	if hasFrac {
+		return sign + b.String() + "." + frac
+	}
+
+	return sign + b.String()
+}
+
+// parse 書式に従った文字列をhyutil.ObjFillが解析できる文字列に戻します
+func (f *Format) parse(val string, tp reflect.Type) (string, error) {
+
+	if f.Null != nil && val == *f.Null {
+		return "", nil
+	}
+
+	// Nullを指定しない場合の空文字と"NULL"は、hyutil.ObjFillがゼロ値にする
+	if f.Null == nil && (val == "" || val == "NULL") {
+		return val, nil
+	}
+
+	if tp == dateTimeType {
+
+		if f.Date == "" {
+			return val, nil
+		}
+
+		t, err := time.ParseInLocation(f.Date, val, time.Local)
+
+		if err != nil {
+			return val, fmt.Errorf("日付の書式(%s)と一致しません", f.Date)
+		}
+
+		return t.Format(hyutil.DateTimeFormat), nil
+	}
+
+	switch tp.Kind() {
+	case reflect.Bool:
+		switch val {
+		case orDefault(f.True, "1"):
+			return "1", nil
+		case orDefault(f.False, "0"):
+			return "0", nil
+		}
+		return val, fmt.Errorf("%s または %s ではありません", orDefault(f.True, "1"), orDefault(f.False, "0"))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if f.Thousands {
+			return strings.ReplaceAll(val, ",", ""), nil
+		}
+	}
+
+	return val, nil
+}
+
+func orDefault(s string, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// formatTags 書式を指定するcsvタグのオプション
+var formatTags = map[string]bool{"format": true, "date": true, "bool": true, "thousands": true, "decimals": true, "null": true}
+
+// setTag tpのフィールドのcsvタグのオプションを設定します
+// formatはhyutil.DateTimeではDate、それ以外ではPrintfです
+func (f *Format) setTag(name string, val string, tp reflect.Type) error {
+
+	switch name {
+	case "format":
+		if tp == dateTimeType {
+			f.Date = val
+		} else {
+			f.Printf = val
+		}
+	case "date":
+		f.Date = val
+	case "bool":
+		t, fl, ok := strings.Cut(val, "/")
+		if !ok {
+			return fmt.Errorf("boolは\"true/false\"の形式で指定してください：%s", val)
+		}
+		f.True, f.False = t, fl
+	case "thousands":
+		f.Thousands = true
+	case "decimals":
+		d, err := strconv.Atoi(val)
+		if err != nil || d < 0 {
+			return fmt.Errorf("decimalsが不正です：%s", val)
+		}
+		f.Decimals = &d
+	case "null":
+		f.Null = &val
+	}
+
+	return nil
+}
+
+// fieldFormat keyのCsvFieldのFormat
+func fieldFormat(fields []CsvField, key string) *Format {
+
+	for i := range fields {
+		if fields[i].Key == key {
+			return fields[i].Format
+		}
+	}
+
+	return nil
+}
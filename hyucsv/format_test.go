@@ -0,0 +1,117 @@
+package hyucsv
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/gara-snake/hyutil"
+	"github.com/gara-snake/hyutil/hyudb"
+)
+
+type formatObj struct {
+	ID     hyudb.DBID
+	Valid  bool
+	Price  float64
+	Count  int
+	Joined hyutil.DateTime
+}
+
+func TestFormatCreate(t *testing.T) {
+
+	is := is.New(t)
+
+	fields := []CsvField{
+		{Key: "id", Label: "ID", Format: &Format{Null: ptr("")}},
+		{Key: "valid", Label: "状態", Format: &Format{True: "有効", False: "無効"}},
+		{Key: "price", Label: "金額", Format: &Format{Thousands: true, Decimals: ptr(2)}},
+		{Key: "count", Label: "件数", Format: &Format{Thousands: true}},
+		{Key: "joined", Label: "入社日", Format: &Format{Date: "2006/01/02", Null: ptr("-")}},
+	}
+
+	c := Create("test", fields, []interface{}{
+		formatObj{ID: 1, Valid: true, Price: 1234567.5, Count: -1234, Joined: hyutil.Date(2020, 4, 1)},
+		&formatObj{Price: 0.125},
+	})
+
+	is.Equal(CsvRow{"id": "1", "valid": "有効", "price": "1,234,567.50", "count": "-1,234", "joined": "2020/04/01"}, c.Rows[0])
+	is.Equal(CsvRow{"id": "", "valid": "無効", "price": "0.12", "count": "0", "joined": "-"}, c.Rows[1])
+
+	var m formatObj
+	is.Equal(0, len(c.DecodeRow(0, &m)))
+	is.Equal(formatObj{ID: 1, Valid: true, Price: 1234567.5, Count: -1234, Joined: m.Joined}, m)
+	is.Equal("2020/04/01", m.Joined.Format("2006/01/02"))
+
+	m = formatObj{}
+	is.Equal(0, len(c.DecodeRow(1, &m)))
+	is.Equal(hyutil.DateTimeZero, m.Joined)
+
+	c.Rows[1]["valid"] = "はい"
+	errs := c.DecodeRow(1, &m)
+	is.Equal(1, len(errs))
+	is.Equal("3行目 状態 'はい' : bool に変換できません（有効 または 無効 ではありません）", errs[0].Error())
+
+	// Formatのない列は従来通り
+	c = Create("test", []CsvField{{Key: "id"}, {Key: "valid"}}, []interface{}{formatObj{}})
+	is.Equal("NULL", c.Rows[0]["id"])
+	is.Equal("0", c.Rows[0]["valid"])
+
+	// Nullを指定しない場合の表記はFormatのない列と同じ
+	c = Create("test", []CsvField{{Key: "id", Format: &Format{}}, {Key: "joined", Format: &Format{Date: "2006/01/02"}}}, []interface{}{formatObj{}})
+	is.Equal("NULL", c.Rows[0]["id"])
+	is.Equal("", c.Rows[0]["joined"])
+	m = formatObj{ID: 5}
+	is.Equal(0, len(c.DecodeRow(0, &m)))
+	is.Equal(hyudb.DBID(0), m.ID)
+
+	// Decimalsの0は整数に丸める
+	c = Create("test", []CsvField{{Key: "price", Format: &Format{Decimals: ptr(0)}}}, []interface{}{formatObj{Price: 12.5}, formatObj{Price: 0.25}})
+	is.Equal("12", c.Rows[0]["price"])
+	is.Equal("0", c.Rows[1]["price"])
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+type formatTagObj struct {
+	Name  string          `csv:"name,label=氏名"`
+	Valid bool            `csv:"valid,label=状態,bool=有効/無効"`
+	Price float64         `csv:"price,label=金額,thousands,decimals=0"`
+	Date  hyutil.DateTime `csv:"date,label=日付,date=2006年1月2日,null=未定"`
+}
+
+func TestFormatTag(t *testing.T) {
+
+	is := is.New(t)
+
+	b, err := Marshal([]formatTagObj{
+		{Name: "山田", Valid: true, Price: 1234.6, Date: hyutil.Date(2021, 12, 3)},
+		{Name: "鈴木"},
+	})
+	is.NoErr(err)
+	is.Equal("\ufeffname:氏名,valid:状態,price:金額,date:日付\r\n"+
+		"山田,有効,\"1,235\",2021年12月3日\r\n"+
+		"鈴木,無効,0,未定\r\n", string(b))
+
+	var ret []formatTagObj
+	is.NoErr(Unmarshal(bytes.NewReader(b), &ret))
+	is.Equal(true, ret[0].Valid)
+	is.Equal(float64(1235), ret[0].Price)
+	is.Equal("2021/12/03", ret[0].Date.Format("2006/01/02"))
+	is.Equal(hyutil.DateTimeZero, ret[1].Date)
+
+	err = Unmarshal(strings.NewReader("氏名,状態\r\n山田,停止\r\n"), &ret)
+	is.Equal("CSVの値が不正です\n2行目 状態 '停止' : bool に変換できません（有効 または 無効 ではありません）", err.Error())
+
+	_, err = Fields(struct {
+		Valid bool `csv:"valid,bool=有効"`
+	}{})
+	is.Err(err)
+
+	_, err = Fields(struct {
+		Price float64 `csv:"price,decimals=-1"`
+	}{})
+	is.Err(err)
+}
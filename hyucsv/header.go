@@ -0,0 +1,152 @@
+package hyucsv
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// CreateTypeAny 列ごとに"キー値:ラベル"、キー値、ラベルのいずれかで一致させる
+var CreateTypeAny CreateType = 2
+
+// ReadOptions 読み込みの設定
+type ReadOptions struct {
+	// Type ヘッダーの照合方法
+	Type CreateType
+	// Encoding 文字コード。既定値は自動判定です
+	Encoding Encoding
+	// DisallowUnknown fieldsのどれにも一致しないヘッダーがある場合はエラーにします
+	DisallowUnknown bool
+	// Dialect 区切り文字などの書式
+	Dialect Dialect
+	// Normalize ヘッダーをNFKCで正規化し、大文字と小文字を区別せずに照合します
+	// falseの場合は前後の空白を除いて完全一致で照合します
+	Normalize bool
+}
+
+// HeaderError ヘッダーの照合に失敗した場合のエラー
+type HeaderError struct {
+	// Missing ヘッダーに見つからなかった必須の列
+	Missing []CsvField
+	// Unknown fieldsのどれにも一致しなかったヘッダー
+	Unknown []string
+}
+
+func (e *HeaderError) Error() string {
+
+	msg := make([]string, 0, 2)
+
+	if 0 < len(e.Missing) {
+
+		labels := make([]string, 0, len(e.Missing))
+
+		for _, f := range e.Missing {
+			labels = append(labels, f.Label)
+		}
+
+		msg = append(msg, "必須の列がありません："+strings.Join(labels, ", "))
+	}
+
+	if 0 < len(e.Unknown) {
+		msg = append(msg, "不明な列があります："+strings.Join(e.Unknown, ", "))
+	}
+
+	return strings.Join(msg, " / ")
+}
+
+// trimHeader 照合用にヘッダーの前後の空白（全角を含む）を除きます
+func trimHeader(s string) string {
+	return strings.Trim(s, " 　")
+}
+
+// normalizeHeader 照合用にヘッダーを正規化します
+// NFKCで全角英数字・記号を半角に、半角カナを全角にし、前後の空白を除いて小文字にします
+func normalizeHeader(s string) string {
+	return strings.ToLower(strings.TrimSpace(norm.NFKC.String(s)))
+}
+
+// headerNames fieldと一致させるヘッダーの候補
+func headerNames(f CsvField, opt CreateType) []string {
+
+	var ret []string
+
+	switch opt {
+	case CreateTypeLabel:
+		ret = []string{f.Label}
+	case CreateTypeAny:
+		ret = []string{f.String(), f.Key, f.Label}
+	default:
+		ret = []string{f.String()}
+	}
+
+	return append(ret, f.Aliases...)
+}
+
+// matchHeader fieldsごとに一致するヘッダーの列番号を返却します。一致しない場合は-1です
+// 同じヘッダーに一致するfieldが複数ある場合は、どのfieldにも同じ列の値を設定します
+func matchHeader(fields []CsvField, header []string, opt ReadOptions) (index []int, unknown []string) {
+
+	normalize := trimHeader
+	if opt.Normalize {
+		normalize = normalizeHeader
+	}
+
+	normalized := make([]string, len(header))
+
+	for i, h := range header {
+		normalized[i] = normalize(h)
+	}
+
+	used := make([]bool, len(header))
+	index = make([]int, len(fields))
+
+	for fi, f := range fields {
+
+		index[fi] = -1
+
+	names:
+		for _, name := range headerNames(f, opt.Type) {
+
+			name = normalize(name)
+
+			if name == "" {
+				continue
+			}
+
+			for i, h := range normalized {
+				if name == h {
+					index[fi] = i
+					used[i] = true
+					break names
+				}
+			}
+		}
+	}
+
+	for i, h := range header {
+		if !used[i] {
+			unknown = append(unknown, h)
+		}
+	}
+
+	return index, unknown
+}
+
+// checkHeader 照合結果から必須の列と不明な列を確認します
+func checkHeader(fields []CsvField, index []int, unknown []string, opt ReadOptions) error {
+
+	err := &HeaderError{}
+
+	for fi, f := range fields {
+		if f.Required && index[fi] < 0 {
+			err.Missing = append(err.Missing, f)
+		}
+	}
+
+	if 0 < len(err.Missing) || (opt.DisallowUnknown && 0 < len(unknown)) {
+		err.Unknown = unknown
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package hyucsv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+)
+
+func TestMatchHeader(t *testing.T) {
+
+	is := is.New(t)
+
+	fields := []CsvField{
+		{Key: "id", Label: "ＩＤ"},
+		{Key: "name", Label: "氏名", Aliases: []string{"名前"}},
+		{Key: "kana", Label: "ｶﾅ"},
+		{Key: "age", Label: "年齢"},
+	}
+
+	index, unknown := matchHeader(fields, []string{" ID ", "名前", "カナ", "備考"}, ReadOptions{Type: CreateTypeLabel, Normalize: true})
+	is.Equal([]int{0, 1, 2, -1}, index)
+	is.Equal([]string{"備考"}, unknown)
+
+	// キー値とラベルの混在
+	index, unknown = matchHeader(fields, []string{"Name", "age:年齢", "　ｉｄ　"}, ReadOptions{Type: CreateTypeAny, Normalize: true})
+	is.Equal([]int{2, 0, -1, 1}, index)
+	is.Equal(0, len(unknown))
+
+	// キー値:ラベルのみ
+	index, _ = matchHeader(fields, []string{"name", "age:年齢"}, ReadOptions{Type: CreateTypeKeyLabel, Normalize: true})
+	is.Equal([]int{-1, -1, -1, 1}, index)
+
+	// Normalizeを指定しない場合は前後の空白のみ除いて完全一致
+	index, unknown = matchHeader(fields, []string{"　ＩＤ ", "ID", "名前", "カナ"}, ReadOptions{Type: CreateTypeLabel})
+	is.Equal([]int{0, 2, -1, -1}, index)
+	is.Equal([]string{"ID", "カナ"}, unknown)
+}
+
+func TestReadDuplicateLabel(t *testing.T) {
+
+	is := is.New(t)
+
+	// 同じラベルのfieldはどちらも同じ列の値になる
+	fields := []CsvField{
+		{Key: "name", Label: "氏名"},
+		{Key: "display_name", Label: "氏名"},
+		{Key: "age", Label: "年齢"},
+	}
+
+	c, err := Read("test", fields, strings.NewReader("氏名,年齢\r\n山田,20\r\n"), ReadOptions{Type: CreateTypeLabel})
+	is.NoErr(err)
+	is.Equal([]CsvRow{{"name": "山田", "display_name": "山田", "age": "20"}}, c.Rows)
+}
+
+func TestReadHeaderError(t *testing.T) {
+
+	is := is.New(t)
+
+	fields := []CsvField{
+		{Key: "id", Label: "ID", Required: true},
+		{Key: "name", Label: "氏名", Required: true},
+		{Key: "memo", Label: "備考"},
+	}
+
+	_, err := Read("test", fields, strings.NewReader("ID,年齢\r\n1,20\r\n"), ReadOptions{Type: CreateTypeLabel})
+	he, ok := err.(*HeaderError)
+	is.True(ok)
+	is.Equal([]CsvField{fields[1]}, he.Missing)
+	is.Equal([]string{"年齢"}, he.Unknown)
+	is.Equal("必須の列がありません：氏名 / 不明な列があります：年齢", err.Error())
+
+	_, err = Read("test", fields, strings.NewReader("ID,氏名,年齢\r\n1,山田,20\r\n"), ReadOptions{Type: CreateTypeLabel, DisallowUnknown: true})
+	is.Equal("不明な列があります：年齢", err.Error())
+
+	c, err := Read("test", fields, strings.NewReader("ID,氏名,年齢\r\n1,山田,20\r\n"), ReadOptions{Type: CreateTypeLabel})
+	is.NoErr(err)
+	is.Equal([]CsvRow{{"id": "1", "name": "山田"}}, c.Rows)
+
+	dec := NewDecoderOptions(strings.NewReader("ID,氏名,年齢\r\n"), fields, ReadOptions{Type: CreateTypeLabel})
+	_, err = dec.Header()
+	is.NoErr(err)
+	is.Equal([]string{"年齢"}, dec.Unknown())
+}
@@ -0,0 +1,174 @@
+package hyucsv
+
+import (
+	"context"
+
+	"github.com/gara-snake/hyutil/hyudb"
+)
+
+// ImportMode 取り込み時の登録方法
+type ImportMode int
+
+const (
+	// ImportInsert 新規に登録します
+	ImportInsert ImportMode = iota
+	// ImportUpsert 主キーまたは一意キーが重複する行は更新します。他のテナントの行は更新しません（hyudb.UpsertMany）
+	ImportUpsert
+)
+
+const defaultBatchSize = 500
+
+// Validator 取り込む行の検証です。モデルに実装するとImportで呼び出されます
+type Validator interface {
+	Validate() error
+}
+
+// ImportOptions 取り込みの設定
+type ImportOptions struct {
+	Mode ImportMode
+	// BatchSize 1回のINSERT文で登録する行数。0の場合は500行
+	BatchSize int
+	// Validate Validatorに加えて行う検証
+	Validate func(obj interface{}) error
+	// Strict 値を変換できないセルが1つでもある場合は何も登録せずに*DecodeErrorを返却します
+	// falseの場合は該当する行のみ登録せずにImportReportに記録します
+	Strict bool
+}
+
+// ImportLine 取り込み結果の1行
+type ImportLine struct {
+	// Line ファイル上の行番号（Csv.Lines）
+	Line   int
+	Row    CsvRow
+	Reason string
+	// Errors 値を変換できなかったセル
+	Errors []*CellError
+}
+
+// ImportReport 取り込み結果
+type ImportReport struct {
+	Accepted []ImportLine
+	Rejected []ImportLine
+}
+
+// HasRejected 取り込めなかった行があるか
+func (r *ImportReport) HasRejected() bool {
+	return 0 < len(r.Rejected)
+}
+
+// Import CSVの各行をTにDecodeして検証し、テーブルに登録します
+// 検証に失敗した行は登録せずに理由をImportReportに記録します。登録時のエラーは全体をロールバックしてエラーを返却します
+func Import[T any](ctx context.Context, db *hyudb.DB, c *Csv, opt ImportOptions) (*ImportReport, error) {
+
+	size := opt.BatchSize
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+
+	if opt.Strict {
+		if err := checkDecode[T](c); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &ImportReport{
+		Accepted: make([]ImportLine, 0),
+		Rejected: make([]ImportLine, 0),
+	}
+
+	err := db.TransactionContext(ctx, func(tx *hyudb.DB) error {
+
+		report.Accepted = report.Accepted[:0]
+		report.Rejected = report.Rejected[:0]
+
+		batch := make([]T, 0, size)
+
+		flush := func() error {
+
+			if len(batch) == 0 {
+				return nil
+			}
+
+			var err error
+
+			if opt.Mode == ImportUpsert {
+				err = hyudb.UpsertMany(ctx, tx, batch)
+			} else {
+				err = hyudb.InsertMany(ctx, tx, batch)
+			}
+
+			batch = batch[:0]
+
+			return err
+		}
+
+		for i := range c.Rows {
+
+			line := ImportLine{Line: c.line(i), Row: c.Rows[i]}
+
+			var m T
+
+			if errs := c.DecodeRow(i, &m); 0 < len(errs) {
+				line.Reason = cellMessages(errs)
+				line.Errors = errs
+				report.Rejected = append(report.Rejected, line)
+				continue
+			}
+
+			if err := validate(&m, opt); err != nil {
+				line.Reason = err.Error()
+				report.Rejected = append(report.Rejected, line)
+				continue
+			}
+
+			report.Accepted = append(report.Accepted, line)
+			batch = append(batch, m)
+
+			if size <= len(batch) {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+
+		return flush()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// checkDecode 全ての行をTに変換できるか確認します
+func checkDecode[T any](c *Csv) error {
+
+	var decErr *DecodeError
+
+	for i := range c.Rows {
+		var m T
+		decErr = decErr.add(c.DecodeRow(i, &m))
+	}
+
+	if decErr != nil {
+		return decErr
+	}
+
+	return nil
+}
+
+func validate(obj interface{}, opt ImportOptions) error {
+
+	if v, ok := obj.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if opt.Validate != nil {
+		return opt.Validate(obj)
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package hyucsv
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/gara-snake/hyutil/hyudb"
+)
+
+type importObj struct {
+	ID   hyudb.DBID `hyudb:"pk"`
+	Name string
+	Age  int
+}
+
+func (m *importObj) TableName() string {
+	return "import_obj"
+}
+
+func (m *importObj) Validate() error {
+	if m.Name == "" {
+		return errors.New("氏名は必須です")
+	}
+	return nil
+}
+
+var importFields = []CsvField{{Key: "name", Label: "name"}, {Key: "age", Label: "age"}}
+
+func readImport(is is.I, src string) *Csv {
+	c, err := Read("test", importFields, strings.NewReader(src), ReadOptions{Type: CreateTypeAny})
+	is.NoErr(err)
+	return c
+}
+
+// importLines 取り込み結果の行番号
+func importLines(lines []ImportLine) []int {
+
+	ret := make([]int, 0, len(lines))
+
+	for _, l := range lines {
+		ret = append(ret, l.Line)
+	}
+
+	return ret
+}
+
+func TestImport(t *testing.T) {
+
+	is := is.New(t)
+
+	db := newFakeDB()
+	defer db.Close()
+
+	// 空行を挟むため、佐藤以降はファイル上の行番号とRowsの位置がずれる
+	c := readImport(is, "name,age\n山田,20\n鈴木,二十\n,30\n\n佐藤,40\n田中,50\n高橋,120\n")
+
+	report, err := Import[importObj](context.Background(), db, c, ImportOptions{
+		BatchSize: 2,
+		Validate: func(obj interface{}) error {
+			if 100 < obj.(*importObj).Age {
+				return errors.New("年齢が不正です")
+			}
+			return nil
+		},
+	})
+	is.NoErr(err)
+
+	is.Equal([]int{2, 6, 7}, importLines(report.Accepted))
+	is.Equal([]int{3, 4, 8}, importLines(report.Rejected))
+	is.True(report.HasRejected())
+
+	is.Equal("鈴木", report.Rejected[0].Row["name"])
+	is.Equal(1, len(report.Rejected[0].Errors))
+	is.Equal("age", report.Rejected[0].Errors[0].Key)
+	is.Equal("氏名は必須です", report.Rejected[1].Reason)
+	is.Equal("年齢が不正です", report.Rejected[2].Reason)
+
+	// BatchSizeごとに登録し、最後にコミットする
+	is.Equal([]string{
+		" INSERT INTO import_obj (`age`,`name` ) VALUES ( 20,'山田' ),( 40,'佐藤' )",
+		" INSERT INTO import_obj (`age`,`name` ) VALUES ( 50,'田中' )",
+		"COMMIT",
+	}, fake.Queries())
+}
+
+func TestImportUpsert(t *testing.T) {
+
+	is := is.New(t)
+
+	db := newFakeDB()
+	defer db.Close()
+
+	report, err := Import[importObj](context.Background(), db, readImport(is, "name,age\n山田,20\n"), ImportOptions{Mode: ImportUpsert})
+	is.NoErr(err)
+	is.False(report.HasRejected())
+
+	q := fake.Queries()
+	is.Equal(2, len(q))
+	is.True(strings.HasSuffix(q[0], "ON DUPLICATE KEY UPDATE `age` = VALUES(`age`),`name` = VALUES(`name`)"))
+}
+
+func TestImportStrict(t *testing.T) {
+
+	is := is.New(t)
+
+	db := newFakeDB()
+	defer db.Close()
+
+	// 変換できないセルがある場合は何も登録しない
+	report, err := Import[importObj](context.Background(), db, readImport(is, "name,age\n山田,20\n鈴木,二十\n"), ImportOptions{Strict: true})
+	is.Nil(report)
+
+	var decErr *DecodeError
+	is.True(errors.As(err, &decErr))
+	is.Equal("CSVの値が不正です\n3行目 age '二十' : int に変換できません（数値ではありません）", err.Error())
+	is.Equal(0, len(fake.Queries()))
+
+	// 検証に失敗した行はStrictでも記録して他の行を登録する
+	report, err = Import[importObj](context.Background(), db, readImport(is, "name,age\n山田,20\n,30\n"), ImportOptions{Strict: true})
+	is.NoErr(err)
+	is.Equal([]int{2}, importLines(report.Accepted))
+	is.Equal([]int{3}, importLines(report.Rejected))
+}
+
+func TestImportRollback(t *testing.T) {
+
+	is := is.New(t)
+
+	fake.Exec = func(query string, args []driver.NamedValue) (driver.Result, error) {
+		return nil, errors.New("Duplicate entry")
+	}
+	defer func() { fake.Exec = nil }()
+
+	db := newFakeDB()
+	defer db.Close()
+
+	report, err := Import[importObj](context.Background(), db, readImport(is, "name,age\n山田,20\n鈴木,30\n"), ImportOptions{BatchSize: 1})
+	is.Nil(report)
+	is.Equal("Duplicate entry", err.Error())
+
+	// 最初の登録で失敗し、全体をロールバックする
+	q := fake.Queries()
+	is.Equal(2, len(q))
+	is.Equal("ROLLBACK", q[1])
+}
@@ -0,0 +1,41 @@
+package hyucsv
+
+import (
+	"context"
+	"io"
+
+	"github.com/gara-snake/hyutil/hyudb"
+)
+
+// WriteQuery queryの結果を1行ずつCSVとしてwに書き込みます。Tableに展開しないため大量の行に使用できます
+// fieldsのKeyをカラム名として値を取得します。ヘッダー、BOM、改行コードはBufferと同じ形式です
+// CsvField.FormatはDBの値が日付、"1"/"0"、数値として解析できる場合に適用し、NULLはFormat.Nullにします
+func WriteQuery(ctx context.Context, w io.Writer, db *hyudb.DB, fields []CsvField, query string, args ...interface{}) error {
+
+	enc := NewEncoder(w, fields)
+
+	err := db.IterateContext(ctx, query, func(r hyudb.Row) error {
+		return enc.Encode(queryRow(r, fields))
+	}, args...)
+
+	if err != nil {
+		enc.Flush()
+		return err
+	}
+
+	return enc.Flush()
+}
+
+// queryRow rのカラムにfieldsの書式を適用します
+func queryRow(r hyudb.Row, fields []CsvField) CsvRow {
+
+	row := CsvRow(r.Columns)
+
+	for _, f := range fields {
+		if f.Format != nil {
+			row[f.Key] = f.Format.formatString(r.Columns[f.Key])
+		}
+	}
+
+	return row
+}
@@ -0,0 +1,47 @@
+package hyucsv
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/gara-snake/hyutil/internal/hyudbtest"
+)
+
+func TestWriteQuery(t *testing.T) {
+
+	is := is.New(t)
+
+	fake.Query = func(query string, args []driver.NamedValue) (*hyudbtest.Rows, error) {
+		return &hyudbtest.Rows{
+			Cols: []string{"id", "price", "valid", "birth", "memo"},
+			Vals: [][]driver.Value{
+				{"1", "1234567.5", "1", "1990-04-01T00:00:00+09:00", "a"},
+				{"2", "0.126", "0", nil, nil},
+			},
+		}, nil
+	}
+	defer func() { fake.Query = nil }()
+
+	db := newFakeDB()
+	defer db.Close()
+
+	fields := []CsvField{
+		{Key: "id", Label: "ID", Format: &Format{Printf: "%03d"}},
+		{Key: "price", Label: "金額", Format: &Format{Thousands: true, Decimals: ptr(2)}},
+		{Key: "valid", Label: "状態", Format: &Format{True: "有効", False: "無効"}},
+		{Key: "birth", Label: "生年月日", Format: &Format{Date: "2006/01/02", Null: ptr("-")}},
+		{Key: "memo", Label: "備考"},
+	}
+
+	var buf bytes.Buffer
+
+	err := WriteQuery(context.Background(), &buf, db, fields, "SELECT * FROM t WHERE id > ?", 0)
+	is.NoErr(err)
+	is.Equal([]string{"SELECT * FROM t WHERE id > ?"}, fake.Queries())
+	is.Equal("\ufeffid:ID,price:金額,valid:状態,birth:生年月日,memo:備考\r\n"+
+		"001,\"1,234,567.50\",有効,1990/04/01,a\r\n"+
+		"002,0.13,無効,-,\r\n", buf.String())
+}
@@ -0,0 +1,420 @@
+package hyucsv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gara-snake/hyutil"
+	"github.com/gara-snake/hyutil/hyudb"
+)
+
+// tagName CSVの定義を指定するタグ名です
+//
+//	Name     string          `csv:"name,label=氏名,order=1,required,alias=名前"`
+//	Birthday hyutil.DateTime `csv:"birthday,label=生年月日,order=2,format=2006/01/02"`
+//	Memo     string          `csv:"-"`
+//
+// keyを省略した場合はjsonタグ、jsonタグもない場合はフィールド名をsnake_caseにしたものです
+// labelを省略した場合はkeyと同じです。aliasは複数指定できます。orderのないフィールドはorderのあるフィールドの後ろに宣言順で並びます
+// format, date, bool, thousands, decimals, null はFormatを指定します。読み込み時も同じFormatで解析します
+// formatはhyutil.DateTimeでは日付の書式(Format.Date)、それ以外ではfmtの書式(Format.Printf)です。formatは最後に指定してください
+// 埋め込み構造体のフィールドは同じ階層の列、入れ子の構造体のフィールドは "address.city" の列になります
+const tagName = "csv"
+
+// schemaField 構造体のフィールドとCSVの列の対応
+type schemaField struct {
+	CsvField
+	// index 構造体のフィールド
+	index []int
+	// fillKey hyutil.ObjFillで使用するキー
+	fillKey string
+	order   int
+}
+
+// fieldKey CsvRowのキー。csvタグ、jsonタグ、フィールド名の順に決定します
+func fieldKey(field reflect.StructField) string {
+
+	if key, _, _ := strings.Cut(field.Tag.Get(tagName), ","); key != "" {
+		return key
+	}
+
+	return fillKey(field)
+}
+
+// fillKey hyutil.ObjFillが使用するキー
+func fillKey(field reflect.StructField) string {
+
+	if key := field.Tag.Get("json"); key != "" {
+		return key
+	}
+
+	return strings.ToLower(hyutil.CamelToSnake(field.Name))
+}
+
+// parseSchema 構造体の型からCSVの定義を作成します
+func parseSchema(tp reflect.Type) ([]schemaField, error) {
+
+	if tp.Kind() == reflect.Ptr {
+		tp = tp.Elem()
+	}
+
+	if tp.Kind() != reflect.Struct {
+		return nil, errors.New("hyucsv:構造体ではありません：" + tp.Kind().String())
+	}
+
+	ret, err := parseStruct(tp, nil, "", "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[j].order < 0 {
+			return 0 <= ret[i].order
+		}
+		return 0 <= ret[i].order && ret[i].order < ret[j].order
+	})
+
+	return ret, nil
+}
+
+// parseStruct 構造体のフィールドからCSVの定義を作成します
+// 埋め込み構造体のフィールドは同じ階層の列に、入れ子の構造体のフィールドは "address.city" のように親のキーを前に付けた列にします
+func parseStruct(tp reflect.Type, parent []int, keyPrefix string, fillPrefix string) ([]schemaField, error) {
+
+	ret := make([]schemaField, 0, tp.NumField())
+
+	for i := 0; i < tp.NumField(); i++ {
+
+		field := tp.Field(i)
+
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get(tagName)
+
+		if tag == "-" {
+			continue
+		}
+
+		index := append(append([]int{}, parent...), field.Index...)
+
+		if hyutil.IsNested(field.Type) {
+
+			if field.Anonymous {
+				nested, err := parseStruct(field.Type, index, keyPrefix, fillPrefix)
+				if err != nil {
+					return nil, err
+				}
+				ret = append(ret, nested...)
+				continue
+			}
+
+			if field.IsExported() {
+				nested, err := parseStruct(field.Type, index, keyPrefix+fieldKey(field)+".", fillPrefix+fillKey(field)+".")
+				if err != nil {
+					return nil, err
+				}
+				ret = append(ret, nested...)
+			}
+
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		sf := schemaField{
+			index:   index,
+			fillKey: fillPrefix + fillKey(field),
+			order:   -1,
+		}
+
+		sf.Key = keyPrefix + fieldKey(field)
+
+		_, opts, _ := strings.Cut(tag, ",")
+
+		for opts != "" {
+
+			var opt string
+
+			if strings.HasPrefix(opts, "format=") {
+				// formatの値には","を含められる
+				opt, opts = opts, ""
+			} else {
+				opt, opts, _ = strings.Cut(opts, ",")
+			}
+
+			name, val, _ := strings.Cut(opt, "=")
+
+			switch name {
+			case "label":
+				sf.Label = val
+			case "order":
+				o, err := strconv.Atoi(val)
+				if err != nil || o < 0 {
+					return nil, fmt.Errorf("hyucsv:%s のorderが不正です：%s", field.Name, val)
+				}
+				sf.order = o
+			case "alias":
+				sf.Aliases = append(sf.Aliases, val)
+			case "required":
+				sf.Required = true
+			default:
+				if formatTags[name] {
+					if sf.Format == nil {
+						sf.Format = &Format{}
+					}
+					if err := sf.Format.setTag(name, val, field.Type); err != nil {
+						return nil, fmt.Errorf("hyucsv:%s の%s", field.Name, err.Error())
+					}
+					continue
+				}
+
+				return nil, fmt.Errorf("hyucsv:%s のタグが不正です：%s", field.Name, opt)
+			}
+		}
+
+		if sf.Label == "" {
+			sf.Label = sf.Key
+		}
+
+		ret = append(ret, sf)
+	}
+
+	return ret, nil
+}
+
+func schemaFields(schema []schemaField) []CsvField {
+
+	ret := make([]CsvField, 0, len(schema))
+
+	for _, sf := range schema {
+		ret = append(ret, sf.CsvField)
+	}
+
+	return ret
+}
+
+// Fields 構造体のcsvタグからCsvFieldを作成します。objは構造体、構造体へのポインター、またはそれらのスライスです
+func Fields(obj interface{}) ([]CsvField, error) {
+
+	tp := reflect.TypeOf(obj)
+
+	if tp == nil {
+		return nil, errors.New("hyucsv:型を取得できません")
+	}
+
+	for tp.Kind() == reflect.Ptr || tp.Kind() == reflect.Slice || tp.Kind() == reflect.Array {
+		tp = tp.Elem()
+	}
+
+	schema, err := parseSchema(tp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return schemaFields(schema), nil
+}
+
+// Marshal 構造体のスライスをcsvタグの定義でCSVに変換します。形式はBufferと同じです
+func Marshal(data interface{}) ([]byte, error) {
+
+	val := reflect.ValueOf(data)
+
+	for val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return nil, errors.New("hyucsv:スライスではありません：" + val.Kind().String())
+	}
+
+	tp := val.Type().Elem()
+
+	// []interface{} の場合は先頭の要素の型を使用する
+	if tp.Kind() == reflect.Interface && 0 < val.Len() {
+		tp = val.Index(0).Elem().Type()
+	}
+
+	schema, err := parseSchema(tp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	enc := NewEncoder(&buf, schemaFields(schema))
+
+	for i := 0; i < val.Len(); i++ {
+
+		v := val.Index(i)
+
+		for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+
+		if v.Type() != tp && !(tp.Kind() == reflect.Ptr && v.Type() == tp.Elem()) {
+			return nil, fmt.Errorf("hyucsv:%d番目の要素の型が異なります：%s", i, v.Type())
+		}
+
+		row := make(CsvRow, len(schema))
+
+		for _, sf := range schema {
+			if sf.Format != nil {
+				row[sf.Key] = sf.Format.format(v.FieldByIndex(sf.index))
+			} else {
+				row[sf.Key] = formatValue(v.FieldByIndex(sf.index))
+			}
+		}
+
+		if err := enc.Encode(row); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal CSVを読み込み、csvタグの定義でvに展開します。vは構造体または構造体へのポインターのスライスへのポインターです
+// ヘッダーは列ごとに"キー値:ラベル"、キー値、ラベルのいずれかで照合し、文字コードは自動判定します
+// 変換できなかったセルがある場合は、全ての行を読み込んだ後に該当するセルの一覧を*DecodeErrorで返却します
+func Unmarshal(r io.Reader, v interface{}) error {
+
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return errors.New("hyucsv:スライスへのポインターではありません")
+	}
+
+	slice := rv.Elem()
+	tp := slice.Type().Elem()
+
+	schema, err := parseSchema(tp)
+
+	if err != nil {
+		return err
+	}
+
+	dec := NewDecoder(r, schemaFields(schema), CreateTypeAny)
+
+	var decErr *DecodeError
+
+	for {
+
+		row, err := dec.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		elem := reflect.New(tp)
+		obj := elem
+
+		if tp.Kind() == reflect.Ptr {
+			elem.Elem().Set(reflect.New(tp.Elem()))
+			obj = elem.Elem()
+		}
+
+		decErr = decErr.add(fillRow(obj.Interface(), row, dec.Line(), schema))
+
+		slice.Set(reflect.Append(slice, elem.Elem()))
+	}
+
+	if decErr != nil {
+		return decErr
+	}
+
+	return nil
+}
+
+// fillRow CsvRowの値をformatで解析してhyutil.ObjFillCheckで展開します
+func fillRow(obj interface{}, row CsvRow, line int, schema []schemaField) []*CellError {
+
+	buf := make(map[string]string, len(schema))
+	byFill := make(map[string]schemaField, len(schema))
+
+	var errs []*CellError
+
+	for _, sf := range schema {
+
+		byFill[sf.fillKey] = sf
+
+		val, ok := row[sf.Key]
+
+		if !ok {
+			continue
+		}
+
+		tp := reflect.TypeOf(obj).Elem().FieldByIndex(sf.index).Type
+
+		if sf.Format != nil {
+
+			v, err := sf.Format.parse(val, tp)
+
+			if err != nil {
+				errs = append(errs, &CellError{
+					Line:   line,
+					Key:    sf.Key,
+					Label:  sf.Label,
+					Value:  val,
+					Type:   tp.String(),
+					Reason: err.Error(),
+				})
+				continue
+			}
+
+			val = v
+		}
+
+		buf[sf.fillKey] = val
+	}
+
+	return append(errs, cellErrors(hyutil.ObjFillCheck(obj, buf, false), line, func(key string) (string, string) {
+		sf := byFill[key]
+		return sf.Key, sf.Label
+	})...)
+}
+
+// formatValue Formatを指定しないフィールドの値をCSVの文字列に変換します
+func formatValue(v reflect.Value) string {
+
+	switch v := v.Interface().(type) {
+	case string:
+		return v
+	case hyutil.DateTime:
+		return v.String()
+	case hyudb.DBID:
+		if v <= 0 {
+			return "NULL"
+		}
+		return fmt.Sprint(v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case nil:
+		return "NULL"
+	}
+
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,169 @@
+package hyucsv
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/gara-snake/hyutil"
+)
+
+type schemaObj struct {
+	ID       int64           `csv:"id,label=ID,order=0"`
+	Name     string          `csv:",label=氏名,order=1"`
+	Birthday hyutil.DateTime `csv:"birth,label=生年月日,order=2,format=2006年01月02日"`
+	Rate     float64         `csv:"rate,format=%.2f"`
+	Kana     string          `json:"name_kana"`
+	Memo     string          `csv:"-"`
+}
+
+func TestSchemaFields(t *testing.T) {
+
+	is := is.New(t)
+
+	fields, err := Fields([]*schemaObj{})
+	is.NoErr(err)
+	is.Equal([]CsvField{
+		{Key: "id", Label: "ID"},
+		{Key: "name", Label: "氏名"},
+		{Key: "birth", Label: "生年月日", Format: &Format{Date: "2006年01月02日"}},
+		{Key: "rate", Label: "rate", Format: &Format{Printf: "%.2f"}},
+		{Key: "name_kana", Label: "name_kana"},
+	}, fields)
+
+	// formatのタグはCsvField.Formatと同じ書式になる
+	c := Create("test", fields, []interface{}{schemaObj{Birthday: hyutil.Date(1990, 4, 1), Rate: 0.5}})
+	is.Equal("1990年04月01日", c.Rows[0]["birth"])
+	is.Equal("0.50", c.Rows[0]["rate"])
+
+	_, err = Fields(struct {
+		A string `csv:"a,order=x"`
+	}{})
+	is.Err(err)
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+
+	is := is.New(t)
+
+	data := []schemaObj{
+		{ID: 1, Name: "山田 太郎", Birthday: hyutil.Date(1990, 4, 1), Rate: 0.5, Kana: "ヤマダ タロウ", Memo: "メモ"},
+		{ID: 2, Name: "鈴木 花子", Rate: 1},
+	}
+
+	b, err := Marshal(data)
+	is.NoErr(err)
+	is.Equal("\ufeffid:ID,name:氏名,birth:生年月日,rate:rate,name_kana:name_kana\r\n"+
+		"1,山田 太郎,1990年04月01日,0.50,ヤマダ タロウ\r\n"+
+		"2,鈴木 花子,,1.00,\r\n", string(b))
+
+	var ret []*schemaObj
+	is.NoErr(Unmarshal(bytes.NewReader(b), &ret))
+	is.Equal(2, len(ret))
+	is.Equal("山田 太郎", ret[0].Name)
+	is.Equal("1990/04/01", ret[0].Birthday.Format("2006/01/02"))
+	is.Equal(0.5, ret[0].Rate)
+	is.Equal("ヤマダ タロウ", ret[0].Kana)
+	is.Equal("", ret[0].Memo)
+	is.Equal(int64(2), ret[1].ID)
+	is.Equal(hyutil.DateTimeZero, ret[1].Birthday)
+
+	var bad []schemaObj
+	err = Unmarshal(bytes.NewReader([]byte("birth:生年月日\r\n1990-04-01\r\n")), &bad)
+	is.Equal("CSVの値が不正です\n2行目 生年月日 '1990-04-01' : hyutil.DateTime に変換できません（日付の書式(2006年01月02日)と一致しません）", err.Error())
+}
+
+type baseModel struct {
+	ID      int64
+	InsDate hyutil.DateTime
+}
+
+type address struct {
+	Pref string `csv:"pref,label=都道府県"`
+	City string `csv:"city,label=市区町村"`
+}
+
+type nestedObj struct {
+	baseModel
+	Name    string  `csv:"name,label=氏名"`
+	Address address `csv:"address"`
+}
+
+func TestNested(t *testing.T) {
+
+	is := is.New(t)
+
+	c := Create("test", []CsvField{
+		{Key: "id"},
+		{Key: "name"},
+		{Key: "address.city"},
+		{Key: "ins_date", Format: &Format{Date: "2006/01/02"}},
+	}, []interface{}{
+		nestedObj{baseModel: baseModel{ID: 1, InsDate: hyutil.Date(2020, 4, 1)}, Name: "山田", Address: address{Pref: "東京都", City: "港区"}},
+	})
+	is.Equal(CsvRow{"id": "1", "ins_date": "2020/04/01", "name": "山田", "address.pref": "東京都", "address.city": "港区"}, c.Rows[0])
+
+	var m nestedObj
+	is.Equal(0, len(c.DecodeRow(0, &m)))
+	is.Equal(int64(1), m.ID)
+	is.Equal("2020/04/01", m.InsDate.Format("2006/01/02"))
+	is.Equal(address{Pref: "東京都", City: "港区"}, m.Address)
+
+	m = nestedObj{}
+	row := CsvRow{"address_pref": "大阪府", "address_city": "北区"}
+	row.Decode(&m)
+	is.Equal(address{Pref: "大阪府", City: "北区"}, m.Address)
+
+	fields, err := Fields(nestedObj{})
+	is.NoErr(err)
+	is.Equal(5, len(fields))
+	is.Equal("address.pref", fields[3].Key)
+	is.Equal("都道府県", fields[3].Label)
+
+	b, err := Marshal([]nestedObj{{baseModel: baseModel{ID: 2}, Name: "鈴木", Address: address{City: "北区"}}})
+	is.NoErr(err)
+	is.Equal("\ufeffid:id,ins_date:ins_date,name:氏名,address.pref:都道府県,address.city:市区町村\r\n"+
+		"2,,鈴木,,北区\r\n", string(b))
+
+	var ret []nestedObj
+	is.NoErr(Unmarshal(bytes.NewReader(b), &ret))
+	is.Equal(1, len(ret))
+	is.Equal(int64(2), ret[0].ID)
+	is.Equal(address{City: "北区"}, ret[0].Address)
+}
+
+func TestNestedUnexported(t *testing.T) {
+
+	is := is.New(t)
+
+	type lockedObj struct {
+		sync.Mutex
+		Name string `json:"name"`
+		memo string
+	}
+
+	c := Create("test", []CsvField{{Key: "name"}}, []interface{}{&lockedObj{Name: "山田", memo: "x"}})
+	is.Equal(CsvRow{"name": "山田"}, c.Rows[0])
+}
+
+func TestNestedPointer(t *testing.T) {
+
+	is := is.New(t)
+
+	type pointerObj struct {
+		*baseModel
+		Name    string   `json:"name"`
+		Address *address `csv:"address"`
+	}
+
+	fields := []CsvField{{Key: "id"}, {Key: "name"}, {Key: "address.city"}}
+
+	// 参照先を展開し、nilの場合は空にする
+	c := Create("test", fields, []interface{}{
+		&pointerObj{baseModel: &baseModel{ID: 1}, Name: "山田", Address: &address{City: "港区"}},
+		&pointerObj{Name: "鈴木"},
+	})
+	is.Equal(CsvRow{"id": "1", "ins_date": "", "name": "山田", "address.pref": "", "address.city": "港区"}, c.Rows[0])
+	is.Equal(CsvRow{"id": "", "ins_date": "", "name": "鈴木", "address.pref": "", "address.city": ""}, c.Rows[1])
+}
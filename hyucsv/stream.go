@@ -0,0 +1,227 @@
+package hyucsv
+
+import (
+	"encoding/csv"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Decoder CSVを1行ずつCsvRowとして読み込みます。ファイル全体をメモリに展開しません
+type Decoder struct {
+	reader  *csv.Reader
+	fields  []CsvField
+	opt     ReadOptions
+	header  []string
+	index   []int
+	unknown []string
+	err     error
+	line    int
+}
+
+// NewDecoder Decoderを作成します。文字コードの判定とヘッダーの照合はCreateFromFileExと同じです
+func NewDecoder(r io.Reader, fields []CsvField, opt CreateType) *Decoder {
+	return NewDecoderOptions(r, fields, ReadOptions{Type: opt})
+}
+
+// NewDecoderOptions 読み込みの設定（文字コード、区切り文字などの書式を含む）を指定してDecoderを作成します
+func NewDecoderOptions(r io.Reader, fields []CsvField, opt ReadOptions) *Decoder {
+
+	reader := newCsvReaderEncoding(r, opt.Encoding)
+	opt.Dialect.apply(reader)
+
+	return &Decoder{
+		reader: reader,
+		fields: fields,
+		opt:    opt,
+	}
+}
+
+// Header ヘッダー行を返却します。未読の場合は読み込みます
+// 必須の列がない場合、またはDisallowUnknownで不明な列がある場合は*HeaderErrorを返却します
+func (d *Decoder) Header() ([]string, error) {
+
+	if d.header != nil {
+		return d.header, d.err
+	}
+
+	header, err := d.reader.Read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	d.header = header
+	d.line, _ = d.reader.FieldPos(0)
+	d.index, d.unknown = matchHeader(d.fields, header, d.opt)
+	d.err = checkHeader(d.fields, d.index, d.unknown, d.opt)
+
+	return d.header, d.err
+}
+
+// Unknown fieldsのどれにも一致しなかったヘッダーを返却します
+func (d *Decoder) Unknown() []string {
+	return d.unknown
+}
+
+// Next 次の行を返却します。終端ではio.EOFを返却します
+func (d *Decoder) Next() (CsvRow, error) {
+
+	if _, err := d.Header(); err != nil {
+		return nil, err
+	}
+
+	record, err := d.reader.Read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	d.line, _ = d.reader.FieldPos(0)
+
+	row := CsvRow{}
+
+	for fi, f := range d.fields {
+
+		i := d.index[fi]
+
+		if i < 0 {
+			continue
+		}
+
+		// 前後の半角と全角の空白を除く
+		row[f.Key] = strings.Trim(record[i], " 　")
+	}
+
+	return row, nil
+}
+
+// Line 直前に読み込んだ行の開始行番号（1始まり）を返却します
+func (d *Decoder) Line() int {
+	return d.line
+}
+
+// Encoder CSVを1行ずつ書き込みます。文字コードの既定値と改行コード(CRLF)はBufferと同じです
+type Encoder struct {
+	// FlushEvery 指定した行数ごとに書き込み先へFlushします。0の場合はバッファが一杯になった時とFlush時のみです
+	FlushEvery int
+
+	writer      recordWriter
+	fields      []CsvField
+	encoding    Encoding
+	unmappable  *unmappable
+	wroteHeader bool
+	count       int
+}
+
+// NewEncoder Encoderを作成します。ヘッダー行は最初の行の書き込み時、またはFlush時に書き込まれます
+func NewEncoder(w io.Writer, fields []CsvField) *Encoder {
+	return NewEncoderOptions(w, fields, WriteOptions{Encoding: EncodingUTF8BOM})
+}
+
+// NewEncoderOptions 書き込みの設定（文字コード、区切り文字などの書式）を指定してEncoderを作成します
+// 文字コードで表現できない文字を含む行は書き込まずに*UnmappableErrorを返却します
+func NewEncoderOptions(w io.Writer, fields []CsvField, opt WriteOptions) *Encoder {
+	return &Encoder{
+		writer:     newRecordWriter(w, opt),
+		fields:     fields,
+		encoding:   opt.Encoding,
+		unmappable: newUnmappable(opt.Encoding),
+	}
+}
+
+// check 表現できない文字があれば*UnmappableErrorを返却します
+func (e *Encoder) check(line int, cols []string) error {
+
+	var err *UnmappableError
+
+	for i, c := range cols {
+		for _, r := range e.unmappable.chars(c) {
+
+			if err == nil {
+				err = &UnmappableError{Encoding: e.encoding}
+			}
+
+			err.Chars = append(err.Chars, Unmappable{Line: line, Key: e.fields[i].Key, Char: r})
+		}
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	return err
+}
+
+func (e *Encoder) writeHeader() error {
+
+	if e.wroteHeader {
+		return nil
+	}
+
+	header := headerRecord(e.fields)
+
+	if err := e.check(1, header); err != nil {
+		return err
+	}
+
+	e.wroteHeader = true
+
+	return e.writer.Write(header)
+}
+
+// Encode 1行書き込みます
+func (e *Encoder) Encode(row CsvRow) error {
+
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	cols := make([]string, 0, len(e.fields))
+
+	for _, f := range e.fields {
+		cols = append(cols, row[f.Key])
+	}
+
+	if err := e.check(e.count+2, cols); err != nil {
+		return err
+	}
+
+	if err := e.writer.Write(cols); err != nil {
+		return err
+	}
+
+	e.count++
+
+	if 0 < e.FlushEvery && e.count%e.FlushEvery == 0 {
+		return e.Flush()
+	}
+
+	return nil
+}
+
+// EncodeObj 構造体をCreateと同じ規則でCsvRowに変換して書き込みます
+func (e *Encoder) EncodeObj(obj interface{}) error {
+
+	val := reflect.ValueOf(obj)
+	tp := val.Type()
+
+	if tp.Kind() == reflect.Ptr {
+		val = val.Elem()
+		tp = tp.Elem()
+	}
+
+	return e.Encode(createCsvRow(val, tp, e.fields))
+}
+
+// Flush バッファの内容を書き込み先へ書き込みます
+func (e *Encoder) Flush() error {
+
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	e.writer.Flush()
+
+	return e.writer.Error()
+}
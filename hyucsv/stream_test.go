@@ -0,0 +1,150 @@
+package hyucsv
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/cheekybits/is"
+)
+
+func TestDecoderTrim(t *testing.T) {
+
+	is := is.New(t)
+
+	fields := []CsvField{{Key: "name", Label: "氏名"}, {Key: "memo", Label: "備考"}}
+
+	dec := NewDecoder(strings.NewReader("氏名,備考\r\n  山田 ,　 鈴木　\r\n"), fields, CreateTypeLabel)
+
+	row, err := dec.Next()
+	is.NoErr(err)
+	is.Equal(CsvRow{"name": "山田", "memo": "鈴木"}, row)
+
+	_, err = dec.Next()
+	is.Equal(io.EOF, err)
+}
+
+// decodeAll Decoderで全ての行を読み込みます
+func decodeAll(is is.I, dec *Decoder) []CsvRow {
+
+	rows := make([]CsvRow, 0)
+
+	for {
+		row, err := dec.Next()
+		if err == io.EOF {
+			return rows
+		}
+		is.NoErr(err)
+		rows = append(rows, row)
+	}
+}
+
+func TestEncoderRoundTrip(t *testing.T) {
+
+	is := is.New(t)
+
+	rows := []CsvRow{
+		{"name": "山田 太郎", "kana": "ﾔﾏﾀﾞ ﾀﾛｳ"},
+		{"name": "髙橋 ①", "kana": "タカハシ"},
+	}
+
+	for _, enc := range []Encoding{EncodingUTF8BOM, EncodingShiftJIS} {
+
+		var buf bytes.Buffer
+
+		e := NewEncoderOptions(&buf, encodingFields, WriteOptions{Encoding: enc})
+		for _, row := range rows {
+			is.NoErr(e.Encode(row))
+		}
+		is.NoErr(e.Flush())
+
+		is.Equal(enc == EncodingUTF8BOM, bytes.HasPrefix(buf.Bytes(), []byte{0xEF, 0xBB, 0xBF}))
+		is.Equal(enc == EncodingUTF8BOM, utf8.Valid(buf.Bytes()))
+
+		for _, read := range []Encoding{enc, EncodingAuto} {
+			dec := NewDecoderOptions(bytes.NewReader(buf.Bytes()), encodingFields, ReadOptions{Type: CreateTypeAny, Encoding: read})
+			is.Equal(rows, decodeAll(is, dec))
+		}
+	}
+}
+
+func TestEncoderHeader(t *testing.T) {
+
+	is := is.New(t)
+
+	// 行がなくてもFlushでヘッダーを書き込む
+	var buf bytes.Buffer
+
+	e := NewEncoderOptions(&buf, encodingFields, WriteOptions{Encoding: EncodingUTF8})
+	is.NoErr(e.Flush())
+	is.Equal("name:氏名,kana:カナ\r\n", buf.String())
+
+	// ヘッダーは最初の1回のみ書き込む
+	buf.Reset()
+
+	e = NewEncoderOptions(&buf, encodingFields, WriteOptions{Encoding: EncodingUTF8})
+	is.NoErr(e.Encode(CsvRow{"name": "山田"}))
+	is.NoErr(e.Encode(CsvRow{"name": "鈴木"}))
+	is.NoErr(e.Flush())
+	is.NoErr(e.Flush())
+	is.Equal("name:氏名,kana:カナ\r\n山田,\r\n鈴木,\r\n", buf.String())
+}
+
+func TestEncoderFlushEvery(t *testing.T) {
+
+	is := is.New(t)
+
+	var buf bytes.Buffer
+
+	e := NewEncoderOptions(&buf, encodingFields, WriteOptions{Encoding: EncodingShiftJIS})
+	e.FlushEvery = 2
+
+	is.NoErr(e.Encode(CsvRow{"name": "山田"}))
+	is.Equal(0, buf.Len())
+
+	// FlushEvery行ごとに書き込み先へ書き込む
+	is.NoErr(e.Encode(CsvRow{"name": "鈴木"}))
+	n := buf.Len()
+	is.True(0 < n)
+
+	is.NoErr(e.Encode(CsvRow{"name": "佐藤"}))
+	is.Equal(n, buf.Len())
+
+	is.NoErr(e.Flush())
+	is.True(n < buf.Len())
+
+	dec := NewDecoderOptions(bytes.NewReader(buf.Bytes()), encodingFields, ReadOptions{Type: CreateTypeAny})
+	is.Equal(3, len(decodeAll(is, dec)))
+}
+
+func TestEncoderUnmappable(t *testing.T) {
+
+	is := is.New(t)
+
+	var buf bytes.Buffer
+
+	e := NewEncoderOptions(&buf, encodingFields, WriteOptions{Encoding: EncodingShiftJIS})
+
+	// 表現できない文字を含む行は書き込まず、次の行は書き込める
+	is.NoErr(e.Encode(CsvRow{"name": "山田"}))
+
+	err := e.Encode(CsvRow{"name": "𠮷田"})
+	u, ok := err.(*UnmappableError)
+	is.True(ok)
+	is.Equal([]Unmappable{{Line: 3, Key: "name", Char: '𠮷'}}, u.Chars)
+
+	is.NoErr(e.Encode(CsvRow{"name": "吉田"}))
+	is.NoErr(e.Flush())
+
+	dec := NewDecoderOptions(bytes.NewReader(buf.Bytes()), encodingFields, ReadOptions{Type: CreateTypeAny})
+	is.Equal([]CsvRow{{"name": "山田", "kana": ""}, {"name": "吉田", "kana": ""}}, decodeAll(is, dec))
+
+	// ヘッダーに表現できない文字がある場合は1行目として返却する
+	e = NewEncoderOptions(&buf, []CsvField{{Key: "name", Label: "氏名😀"}}, WriteOptions{Encoding: EncodingShiftJIS})
+	err = e.Flush()
+	u, ok = err.(*UnmappableError)
+	is.True(ok)
+	is.Equal([]Unmappable{{Line: 1, Key: "name", Char: '😀'}}, u.Chars)
+}
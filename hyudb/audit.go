@@ -0,0 +1,305 @@
+package hyudb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/gara-snake/hyutil"
+)
+
+// AuditAction 変更履歴の操作種別
+type AuditAction string
+
+const (
+	// AuditInsert 新規作成
+	AuditInsert AuditAction = "insert"
+	// AuditUpdate 更新
+	AuditUpdate AuditAction = "update"
+	// AuditDelete 削除
+	AuditDelete AuditAction = "delete"
+)
+
+// AuditRecord カラム単位の変更履歴です
+type AuditRecord struct {
+	Table    string
+	PK       string
+	Column   string
+	OldValue string
+	NewValue string
+	Action   AuditAction
+	Actor    string
+	At       hyutil.DateTime
+}
+
+// AuditSink は変更履歴の出力先です
+// txはSave、DelForeverと同じトランザクションの参照で、同一トランザクション内で記録する場合に使用します
+type AuditSink interface {
+	WriteAudit(ctx context.Context, tx *DB, records []AuditRecord) error
+}
+
+// AuditSinkFunc は関数をAuditSinkとして扱う型です
+type AuditSinkFunc func(ctx context.Context, tx *DB, records []AuditRecord) error
+
+// WriteAudit fを呼び出します
+func (f AuditSinkFunc) WriteAudit(ctx context.Context, tx *DB, records []AuditRecord) error {
+	return f(ctx, tx, records)
+}
+
+// AuditTable は変更履歴を同一トランザクション内でテーブルに記録するAuditSinkです
+//
+//	CREATE TABLE audit_log (
+//	  id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+//	  table_name  VARCHAR(64)  NOT NULL,
+//	  pk          VARCHAR(64)  NOT NULL,
+//	  column_name VARCHAR(64)  NOT NULL,
+//	  old_value   TEXT,
+//	  new_value   TEXT,
+//	  action      VARCHAR(16)  NOT NULL,
+//	  actor       VARCHAR(255) NOT NULL,
+//	  ins_date    DATETIME     NOT NULL
+//	)
+type AuditTable struct {
+	Name string
+}
+
+// WriteAudit 変更履歴をテーブルに登録します
+func (at *AuditTable) WriteAudit(ctx context.Context, tx *DB, records []AuditRecord) error {
+
+	query :=
+		" INSERT INTO " + at.Name +
+			" (`table_name`,`pk`,`column_name`,`old_value`,`new_value`,`action`,`actor`,`ins_date`) " +
+			" VALUES (?,?,?,?,?,?,?,?) "
+
+	for _, r := range records {
+
+		_, _, err := tx.ExecContext(ctx, query,
+			r.Table, r.PK, r.Column, r.OldValue, r.NewValue, string(r.Action), r.Actor, r.At.Format(dbDatetimeFormat))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// auditTx 変更と変更履歴をfnで同じトランザクション内に記録します
+// トランザクション外の場合は、複数のゴルーチンから使用される参照の状態を変更しないようSessionで開始します
+func (db *DB) auditTx(ctx context.Context, fn func(tx *DB) error) error {
+
+	if db.transaction != nil {
+		return fn(db)
+	}
+
+	return db.Session().TransactionContext(ctx, fn)
+}
+
+func (db *DB) auditSave(ctx context.Context, model interface{}) error {
+
+	val, tp, err := modelStruct(model)
+
+	if err != nil {
+		return err
+	}
+
+	pk, ok := modelPK(tp)
+
+	if !ok {
+		return errors.New("プライマリーキーの指定がありません")
+	}
+
+	action := AuditInsert
+	var before map[string]string
+
+	if fmt.Sprint(val.FieldByName(pk.Name).Interface()) != fmt.Sprint(NoID) {
+
+		action = AuditUpdate
+
+		if before, err = db.loadAuditValues(ctx, val, tp, pk); err != nil {
+			return err
+		}
+	}
+
+	if err := db.save(ctx, model); err != nil {
+		return err
+	}
+
+	return db.writeAudit(ctx, action, model, val, tp, pk, before, auditValues(val, tp))
+}
+
+func (db *DB) auditDel(ctx context.Context, model interface{}) error {
+
+	val, tp, err := modelStruct(model)
+
+	if err != nil {
+		return err
+	}
+
+	pk, ok := modelPK(tp)
+
+	if !ok {
+		return errors.New("プライマリーキーの指定がありません")
+	}
+
+	before, err := db.loadAuditValues(ctx, val, tp, pk)
+
+	if err != nil {
+		return err
+	}
+
+	if err := db.del(ctx, model); err != nil {
+		return err
+	}
+
+	return db.writeAudit(ctx, AuditDelete, model, val, tp, pk, before, nil)
+}
+
+// loadAuditValues 登録済みの行をGetと同じ方法で取得し、変更履歴用の値を返却します。行がない場合はnilです
+func (db *DB) loadAuditValues(ctx context.Context, val reflect.Value, tp reflect.Type, pk reflect.StructField) (map[string]string, error) {
+
+	stored := reflect.New(tp)
+	stored.Elem().FieldByName(pk.Name).Set(val.FieldByName(pk.Name))
+
+	query, err := createSelectQuery(stored.Interface())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if query, err = scopeQuery(ctx, query, tp); err != nil {
+		return nil, err
+	}
+
+	tbl, err := db.QueryContext(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tbl.Rows) <= 0 {
+		return nil, nil
+	}
+
+	if err := dbFill(stored.Interface(), &tbl.Rows[0]); err != nil {
+		return nil, err
+	}
+
+	return auditValues(stored.Elem(), tp), nil
+}
+
+func (db *DB) writeAudit(ctx context.Context, action AuditAction, model interface{}, val reflect.Value, tp reflect.Type, pk reflect.StructField,
+	before map[string]string, after map[string]string) error {
+
+	cols := make([]string, 0, len(before)+len(after))
+
+	for c := range after {
+		cols = append(cols, c)
+	}
+
+	for c := range before {
+		if _, ok := after[c]; !ok {
+			cols = append(cols, c)
+		}
+	}
+
+	sort.Strings(cols)
+
+	table := modelTableName(model, tp)
+	pkVal := fmt.Sprint(val.FieldByName(pk.Name).Interface())
+	actor := ActorFrom(ctx)
+	now := hyutil.NowDateTime()
+
+	encrypted := make(map[string]bool)
+	for _, c := range encryptedColumns(tp) {
+		encrypted[c] = true
+	}
+
+	records := make([]AuditRecord, 0)
+
+	for _, c := range cols {
+
+		if before[c] == after[c] {
+			continue
+		}
+
+		oldVal, newVal := before[c], after[c]
+
+		// 暗号化カラムは変更の有無のみ記録する
+		if encrypted[c] {
+			oldVal, newVal = maskAuditValue(oldVal), maskAuditValue(newVal)
+		}
+
+		records = append(records, AuditRecord{
+			Table:    table,
+			PK:       pkVal,
+			Column:   c,
+			OldValue: oldVal,
+			NewValue: newVal,
+			Action:   action,
+			Actor:    actor,
+			At:       now,
+		})
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	return db.Audit.WriteAudit(ctx, db, records)
+}
+
+func maskAuditValue(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "***"
+}
+
+// auditValues 変更比較用にカラムごとの値を文字列化します
+func auditValues(val reflect.Value, tp reflect.Type) map[string]string {
+
+	ret := make(map[string]string)
+
+	for i := 0; i < tp.NumField(); i++ {
+
+		field := tp.Field(i)
+
+		key := field.Tag.Get("hyudb")
+
+		if key == "pk" || key == "non" || field.PkgPath != "" {
+			continue
+		}
+
+		col := colName(field)
+
+		if dv, ok, err := driverValue(field, val.Field(i)); ok {
+			if err == nil {
+				ret[col] = driverValueString(dv)
+			}
+			continue
+		}
+
+		switch v := val.Field(i).Interface().(type) {
+		case string:
+			ret[col] = v
+		case hyutil.DateTime:
+			if v != hyutil.DateTimeZero {
+				ret[col] = v.Format(dbDatetimeFormat)
+			}
+		case DBID:
+			if 0 < v {
+				ret[col] = fmt.Sprint(v)
+			}
+		case bool:
+			ret[col] = DbBool(v)
+		default:
+			ret[col] = fmt.Sprint(v)
+		}
+
+	}
+
+	return ret
+}
@@ -0,0 +1,122 @@
+package hyudb
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/gara-snake/hyutil"
+	"github.com/gara-snake/hyutil/internal/hyudbtest"
+)
+
+type auditObj struct {
+	ID      DBID `hyudb:"pk"`
+	Name    string
+	Age     int32
+	Invalid bool
+	UpdDate hyutil.DateTime
+	Memo    string `hyudb:"non"`
+}
+
+func (a *auditObj) TableName() string {
+	return "audit_obj"
+}
+
+func TestWriteAudit(t *testing.T) {
+
+	is := is.New(t)
+
+	var got []AuditRecord
+
+	db := &DB{
+		Audit: AuditSinkFunc(func(ctx context.Context, tx *DB, records []AuditRecord) error {
+			got = records
+			return nil
+		}),
+	}
+
+	before := &auditObj{ID: 7, Name: "テスト太郎", Age: 15}
+	after := &auditObj{ID: 7, Name: "テスト次郎", Age: 15, Invalid: true, Memo: "memo"}
+
+	val := reflect.ValueOf(after).Elem()
+	tp := val.Type()
+	pk, _ := modelPK(tp)
+
+	ctx := WithActor(context.Background(), "admin")
+
+	err := db.writeAudit(ctx, AuditUpdate, after, val, tp, pk, auditValues(reflect.ValueOf(before).Elem(), tp), auditValues(val, tp))
+	is.NoErr(err)
+
+	is.Equal(2, len(got))
+
+	is.Equal("audit_obj", got[0].Table)
+	is.Equal("7", got[0].PK)
+	is.Equal("invalid", got[0].Column)
+	is.Equal("0", got[0].OldValue)
+	is.Equal("1", got[0].NewValue)
+	is.Equal("admin", got[0].Actor)
+	is.Equal(AuditUpdate, got[0].Action)
+
+	is.Equal("name", got[1].Column)
+	is.Equal("テスト太郎", got[1].OldValue)
+	is.Equal("テスト次郎", got[1].NewValue)
+
+}
+
+func TestDelIsNoop(t *testing.T) {
+
+	is := is.New(t)
+
+	// 論理削除は未実装のため、Auditの有無にかかわらずクエリを実行しない
+	db := &DB{Audit: AuditSinkFunc(func(ctx context.Context, tx *DB, records []AuditRecord) error {
+		t.Fatal("WriteAudit")
+		return nil
+	})}
+
+	is.NoErr(db.Del(&auditObj{ID: 1}))
+
+	query, err := createDeleteForeverQuery(&auditObj{ID: 1})
+	is.NoErr(err)
+	is.Equal(" DELETE FROM audit_obj WHERE `id` = 1", query)
+}
+
+func TestAuditSaveConcurrent(t *testing.T) {
+
+	is := is.New(t)
+
+	var mu sync.Mutex
+	count := 0
+
+	c := &hyudbtest.Connector{}
+	db := newFakeDB(c)
+	db.Audit = AuditSinkFunc(func(ctx context.Context, tx *DB, records []AuditRecord) error {
+		mu.Lock()
+		defer mu.Unlock()
+		count++
+		return nil
+	})
+
+	// 変更履歴のトランザクションは共有された参照の状態を変更しない (go test -race)
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- db.Save(&auditObj{Name: "テスト"})
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		is.NoErr(err)
+	}
+
+	is.Equal(8, count)
+	is.Nil(db.transaction)
+}
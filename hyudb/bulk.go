@@ -0,0 +1,155 @@
+package hyudb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// InsertMany 複数のモデルを1つのINSERT文で登録します。採番されたIDはモデルに設定されません
+// Auditは記録されません
+func InsertMany[T any](ctx context.Context, db *DB, models []T) error {
+	return insertMany(ctx, db, models, false)
+}
+
+// UpsertMany 複数のモデルを1つのINSERT文で登録し、主キーまたは一意キーが重複する行は更新します
+// 主キーが設定されたモデルは主キーも登録対象になります。Auditは記録されません
+// テナントで分離されたモデルの場合、重複した行が他のテナントの行であれば更新しません（エラーにもなりません）
+func UpsertMany[T any](ctx context.Context, db *DB, models []T) error {
+	return insertMany(ctx, db, models, true)
+}
+
+func insertMany[T any](ctx context.Context, db *DB, models []T, upsert bool) error {
+
+	if len(models) == 0 {
+		return nil
+	}
+
+	query, err := createInsertManyQuery(ctx, models, upsert)
+
+	if err != nil {
+		return err
+	}
+
+	_, _, err = db.ExecContext(ctx, query)
+
+	return err
+}
+
+func createInsertManyQuery[T any](ctx context.Context, models []T, upsert bool) (string, error) {
+
+	var tableName string
+	var columns []string
+	var pkCol string
+	var tenantCol string
+
+	rows := make([]map[string]string, 0, len(models))
+
+	for i := range models {
+
+		// Tが構造体、構造体へのポインターのどちらでも扱えるようにする
+		var model interface{} = &models[i]
+		if reflect.TypeOf(models[i]).Kind() == reflect.Ptr {
+			model = models[i]
+		}
+
+		val, tp, err := modelStruct(model)
+
+		if err != nil {
+			return "", err
+		}
+
+		pk, ok := modelPK(tp)
+
+		if !ok {
+			return "", errors.New("プライマリーキーの指定がありません")
+		}
+
+		if err := applyTenant(ctx, val, tp); err != nil {
+			return "", err
+		}
+
+		colVal, err := createColValMap(val, tp, mapIns)
+
+		if err != nil {
+			return "", err
+		}
+
+		// 主キー指定の行がある場合は主キーも登録する。未指定の行はNULLで採番させる
+		pkCol = ColEsc(colName(pk))
+		if pkv := val.FieldByIndex(pk.Index); !pkv.IsZero() {
+			colVal[pkCol] = pkLiteral(pkv)
+		}
+
+		if i == 0 {
+			tableName = modelTableName(model, tp)
+			if field, ok := modelTenant(tp); ok {
+				tenantCol = ColEsc(colName(field))
+			}
+		}
+
+		rows = append(rows, colVal)
+	}
+
+	seen := make(map[string]bool)
+
+	for _, r := range rows {
+		for c := range r {
+			if !seen[c] {
+				seen[c] = true
+				columns = append(columns, c)
+			}
+		}
+	}
+
+	sort.Strings(columns)
+
+	values := make([]string, 0, len(rows))
+
+	for _, r := range rows {
+
+		vals := make([]string, len(columns))
+
+		for i, c := range columns {
+			if v, ok := r[c]; ok {
+				vals[i] = v
+			} else {
+				vals[i] = "NULL"
+			}
+		}
+
+		values = append(values, "( "+strings.Join(vals, ",")+" )")
+	}
+
+	query :=
+		" INSERT INTO " + tableName + " (" +
+			strings.Join(columns, ",") +
+			" ) VALUES " +
+			strings.Join(values, ",")
+
+	if upsert {
+
+		sets := make([]string, 0, len(columns))
+
+		for _, c := range columns {
+
+			if c == pkCol || c == tenantCol {
+				continue
+			}
+
+			// 他のテナントの行は変更せず、テナントも移さない
+			if tenantCol != "" {
+				sets = append(sets, c+" = IF("+tenantCol+" = VALUES("+tenantCol+"), VALUES("+c+"), "+c+")")
+				continue
+			}
+
+			sets = append(sets, c+" = VALUES("+c+")")
+		}
+
+		query += " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ",")
+	}
+
+	return query, nil
+}
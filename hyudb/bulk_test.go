@@ -0,0 +1,39 @@
+package hyudb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cheekybits/is"
+)
+
+func TestCreateInsertManyQuery(t *testing.T) {
+
+	is := is.New(t)
+
+	models := []*auditObj{
+		{Name: "テスト太郎", Age: 15},
+		{ID: 5, Name: "テスト次郎", Age: 20, Invalid: true},
+	}
+
+	q, err := createInsertManyQuery(context.Background(), models, false)
+	is.NoErr(err)
+	is.Equal(" INSERT INTO audit_obj (`age`,`id`,`invalid`,`name`,`upd_date` ) VALUES "+
+		"( 15,NULL,0,'テスト太郎',NULL ),( 20,5,1,'テスト次郎',NULL )", q)
+
+	q, err = createInsertManyQuery(context.Background(), []auditObj{{ID: 5, Name: "テスト次郎"}}, true)
+	is.NoErr(err)
+	is.Equal(" INSERT INTO audit_obj (`age`,`id`,`invalid`,`name`,`upd_date` ) VALUES "+
+		"( 0,5,0,'テスト次郎',NULL ) ON DUPLICATE KEY UPDATE `age` = VALUES(`age`),`invalid` = VALUES(`invalid`),"+
+		"`name` = VALUES(`name`),`upd_date` = VALUES(`upd_date`)", q)
+
+	_, err = createInsertManyQuery(context.Background(), []tenantObj{{Name: "テスト"}}, false)
+	is.Equal(ErrNoTenant, err)
+
+	// 他のテナントの行は更新せず、テナントの列も更新しない
+	q, err = createInsertManyQuery(WithTenant(context.Background(), 3), []tenantObj{{ID: 5, Name: "テスト"}}, true)
+	is.NoErr(err)
+	is.Equal(" INSERT INTO tenant_obj (`id`,`name`,`tenant_id` ) VALUES "+
+		"( 5,'テスト',3 ) ON DUPLICATE KEY UPDATE `name` = IF(`tenant_id` = VALUES(`tenant_id`), VALUES(`name`), `name`)", q)
+
+}
@@ -0,0 +1,49 @@
+package hyudb
+
+import "context"
+
+type ctxKey int
+
+const (
+	ctxKeyForcePrimary ctxKey = iota
+	ctxKeyActor
+	ctxKeyTenant
+	ctxKeyLock
+)
+
+// ForcePrimary は参照系のクエリもプライマリーで実行させるcontextを返却します
+// 更新直後の読み込みなど、レプリカの遅延が許されない場合に使用します
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxKeyForcePrimary, true)
+}
+
+func isForcePrimary(ctx context.Context) bool {
+	b, _ := ctx.Value(ctxKeyForcePrimary).(bool)
+	return b
+}
+
+// WithActor は変更履歴に記録する操作者を設定したcontextを返却します
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, ctxKeyActor, actor)
+}
+
+// ActorFrom はcontextに設定された操作者を返却します
+func ActorFrom(ctx context.Context) string {
+	s, _ := ctx.Value(ctxKeyActor).(string)
+	return s
+}
+
+// WithTenant は操作対象のテナントを設定したcontextを返却します
+// hyudb:"tenant" のフィールドを持つモデルは、このテナントの行のみ参照、更新できます
+// 条件が追加されるのはGet、Save、DelForever、Repoが作成するクエリのみです
+// SelectQuery、Exec、Iterateなどに渡すSQLには追加されないため、テナントの条件を記述してください
+// 他のテナントの行を更新、削除しようとした場合はErrNotFoundになります
+func WithTenant(ctx context.Context, tenant DBID) context.Context {
+	return context.WithValue(ctx, ctxKeyTenant, tenant)
+}
+
+// TenantFrom はcontextに設定されたテナントを返却します
+func TenantFrom(ctx context.Context) (DBID, bool) {
+	t, ok := ctx.Value(ctxKeyTenant).(DBID)
+	return t, ok
+}
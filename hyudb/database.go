@@ -4,6 +4,7 @@ package hyudb
 //import _ "github.com/go-sql-driver/mysql"
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -18,15 +19,27 @@ import (
 // NoID はInt型プライマリーキーの新規値です
 const NoID = 0
 
+// ErrNotFound はGet、Findで該当するレコードがない場合のエラーです
+var ErrNotFound = errors.New("DB.Get:該当するレコードがありません")
+
 const dbTimeFormat = "2006-01-02T15:04:05-07:00"
 
 // DB への参照です
 type DB struct {
-	IsOpen      bool
-	Debug       bool
+	IsOpen bool
+	Debug  bool
+	// Retry はTransactionでの再試行方針です。nilの場合はDefaultRetryPolicyが使用されます
+	Retry *RetryPolicy
+	// Audit はSave、DelForeverでの変更履歴の出力先です。nilの場合は記録しません
+	Audit AuditSink
+
 	connection  *sql.DB
+	replicas    *replicaSet
 	transaction *sql.Tx
 	hasErr      bool
+	err         error
+	// shared Sessionで作成された参照の場合true。Closeで接続を閉じません
+	shared bool
 }
 
 // Row カラム名ごとに文字列型で値を代入したMap
@@ -96,7 +109,8 @@ func ColEsc(s string) string {
 }
 
 // New データベースへの新規接続を開始します
-func New(dbType string, connectionstr string) *DB {
+// replicasを指定した場合、参照系のクエリはトランザクション外であればレプリカへ振り分けられます
+func New(dbType string, connectionstr string, replicas ...string) *DB {
 
 	db, err := sql.Open(dbType, connectionstr)
 
@@ -109,14 +123,35 @@ func New(dbType string, connectionstr string) *DB {
 		IsOpen:     true,
 		Debug:      false,
 		connection: db,
+		replicas:   openReplicaSet(dbType, replicas),
 		hasErr:     false,
 	}
 
 }
 
 // MysqlNew 任意のMysqlサーバへの接続を開始します
-func MysqlNew(connectionstr string) *DB {
-	return New("mysql", connectionstr)
+func MysqlNew(connectionstr string, replicas ...string) *DB {
+	return New("mysql", connectionstr, replicas...)
+}
+
+// Session は接続を共有し、トランザクションの状態を分けた参照を返却します
+// 複数のゴルーチンでそれぞれトランザクションを使用する場合に、ゴルーチンごとに作成してください
+func (db *DB) Session() *DB {
+	return &DB{
+		IsOpen:     db.IsOpen,
+		Debug:      db.Debug,
+		Retry:      db.Retry,
+		Audit:      db.Audit,
+		connection: db.connection,
+		replicas:   db.replicas,
+		shared:     true,
+	}
+}
+
+// Conn 接続プールの接続を1つ固定して返却します
+// セッション変数を変更する場合など、同じ接続で実行する必要がある場合に使用し、使用後はCloseしてください
+func (db *DB) Conn(ctx context.Context) (*sql.Conn, error) {
+	return db.connection.Conn(ctx)
 }
 
 // BeginTx トランザクションを開始します
@@ -145,6 +180,7 @@ func (db *DB) Rollback() {
 }
 
 // Close DBへの接続を閉じます。未完了のトランザクションは”コミット”されます
+// Sessionで作成された参照の場合、共有している接続は閉じません
 func (db *DB) Close() {
 
 	if err := recover(); err != nil {
@@ -163,16 +199,36 @@ func (db *DB) Close() {
 			}
 		}
 
-		db.connection.Close()
+		if !db.shared {
+			db.connection.Close()
+		}
 		db.IsOpen = false
 		db.connection = nil
 	}
 
+	if db.replicas != nil {
+		if !db.shared {
+			db.replicas.close()
+		}
+		db.replicas = nil
+	}
+
 }
 
 // Exec INSERT、UPDATE、DELETEを実行します RowsAffected LastInsertId
+// エラーの場合はログを出力し-1を返却します。Transaction内ではロールバックされ、再試行可能なエラーであれば再試行されます
+// エラーを受け取る場合はExecContextを使用してください
 func (db *DB) Exec(query string) (int64, int64) {
 
+	ret1, ret2, _ := db.ExecContext(context.Background(), query)
+
+	return ret1, ret2
+
+}
+
+// ExecContext INSERT、UPDATE、DELETEを実行します RowsAffected LastInsertId
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (int64, int64, error) {
+
 	if db.Debug {
 		log.Println("EXEC QUERY : " + query)
 	}
@@ -181,68 +237,119 @@ func (db *DB) Exec(query string) (int64, int64) {
 	var err error
 
 	if db.transaction == nil {
-		result, err = db.connection.Exec(query)
+		result, err = db.connection.ExecContext(ctx, query, args...)
 	} else {
-		result, err = db.transaction.Exec(query)
+		result, err = db.transaction.ExecContext(ctx, query, args...)
 	}
 
 	if err != nil {
-		log.Fatalln(err)
-		db.hasErr = true
+		log.Println("Error query : " + query)
+		log.Println(err)
+		db.setErr(err)
+		return -1, -1, err
 	}
 
-	ret1, err := result.RowsAffected()
+	ret1, _ := result.RowsAffected()
 	ret2, err := result.LastInsertId()
 
 	if err != nil {
 		log.Println("Error query : " + query)
 		log.Println(err)
-		ret1 = 0
-		ret2 = NoID
-		db.hasErr = true
-		return -1, -1
+		db.setErr(err)
+		return -1, -1, err
 	}
 
-	return ret1, ret2
+	return ret1, ret2, nil
+
+}
+
+// setErr エラーを記録し、トランザクションをロールバック対象にします
+func (db *DB) setErr(err error) {
+
+	// トランザクション外では使用されず、共有された参照を複数のゴルーチンから使用できるよう記録しない
+	if db.transaction == nil {
+		return
+	}
+
+	db.hasErr = true
+
+	if db.err == nil {
+		db.err = err
+	}
 
 }
 
 // SelectExists queryで行が取得できたかどうかを返却します
 func (db *DB) SelectExists(query string) bool {
+	return db.SelectExistsContext(context.Background(), query)
+}
+
+// SelectExistsContext queryで行が取得できたかどうかを返却します
+// エラーの場合はログを出力しfalseを返却します。エラーを受け取る場合はExistsContextを使用してください
+func (db *DB) SelectExistsContext(ctx context.Context, query string) bool {
+
+	ret, _ := db.ExistsContext(ctx, query)
+
+	return ret
+}
+
+// ExistsContext queryで行が取得できたかどうかを返却します
+func (db *DB) ExistsContext(ctx context.Context, query string, args ...interface{}) (bool, error) {
 
 	if db.Debug {
 		log.Println("SELECT QUERY : " + query)
 	}
 
-	var rows *sql.Rows
-	var err error
-
-	if db.transaction == nil {
-		rows, err = db.connection.Query(query)
-	} else {
-		rows, err = db.transaction.Query(query)
-	}
+	rows, err := db.readQuery(ctx, query, args...)
 
 	if err != nil {
-		log.Fatalln(err)
-		db.hasErr = true
-		return false
+		return false, db.readErr(query, err)
 	}
 
 	defer rows.Close()
 
-	return rows.Next()
+	if rows.Next() {
+		return true, nil
+	}
 
+	if err := rows.Err(); err != nil {
+		return false, db.readErr(query, err)
+	}
+
+	return false, nil
+}
+
+// readErr 参照系クエリのエラーをログに出力します
+// 再試行可能なエラーはトランザクションが継続できないため記録し、Transactionでのロールバックと再試行の対象にします
+func (db *DB) readErr(query string, err error) error {
+
+	log.Println("Error query : " + query)
+	log.Println(err)
+
+	if IsRetryable(err) {
+		db.setErr(err)
+	}
+
+	return err
 }
 
 // SelectTop queryを実行し、先頭の要素をDBFillします
 func (db *DB) SelectTop(query string, model interface{}) error {
+	return db.SelectTopContext(context.Background(), query, model)
+}
+
+// SelectTopContext queryを実行し、先頭の要素をDBFillします
+func (db *DB) SelectTopContext(ctx context.Context, query string, model interface{}) error {
+
+	tbl, err := db.QueryContext(ctx, query)
+
+	if err != nil {
+		return err
+	}
 
-	tbl := db.SelectQuery(query)
 	for _, r := range tbl.Rows {
 
-		DBFill(model, &r)
-		return nil
+		return dbFill(model, &r)
 
 	}
 
@@ -252,7 +359,12 @@ func (db *DB) SelectTop(query string, model interface{}) error {
 // SelectCount queryを実行し、先頭の要素、列名countsをintで返却します
 func (db *DB) SelectCount(query string) (int, error) {
 
-	tbl := db.SelectQuery(query)
+	tbl, err := db.QueryContext(context.Background(), query)
+
+	if err != nil {
+		return 0, err
+	}
+
 	for _, r := range tbl.Rows {
 
 		c := r.Columns["counts"]
@@ -266,128 +378,195 @@ func (db *DB) SelectCount(query string) (int, error) {
 
 // SelectQuery SELECTを実行します
 func (db *DB) SelectQuery(query string) *Table {
+	return db.SelectQueryContext(context.Background(), query)
+}
+
+// SelectQueryContext SELECTを実行します
+// エラーの場合はログを出力し空のTableを返却します。エラーを受け取る場合はQueryContextを使用してください
+func (db *DB) SelectQueryContext(ctx context.Context, query string) *Table {
+
+	ret, err := db.QueryContext(ctx, query)
+
+	if err != nil {
+		return &Table{Rows: make([]Row, 0)}
+	}
+
+	return ret
+
+}
+
+// QueryContext SELECTを実行します
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*Table, error) {
 
 	if db.Debug {
 		log.Println("SELECT QUERY : " + query)
 	}
 
-	var rows *sql.Rows
-	var err error
+	rows, err := db.readQuery(ctx, query, args...)
 
-	if db.transaction == nil {
-		rows, err = db.connection.Query(query)
-	} else {
-		rows, err = db.transaction.Query(query)
+	if err != nil {
+		return nil, db.readErr(query, err)
 	}
 
 	defer rows.Close()
 
-	if err != nil {
-		log.Println("Error query : " + query)
-		log.Fatalln(err)
-		db.hasErr = true
+	var ret = Table{
+		Rows: make([]Row, 0),
+	}
+
+	err = scanRows(rows, func(r Row) error {
+		ret.Rows = append(ret.Rows, r)
 		return nil
+	})
+
+	if err != nil {
+		return nil, db.readErr(query, err)
 	}
 
-	columns, err := rows.Columns()
+	return &ret, nil
+
+}
+
+// Get でmodelのプライマリーキーでデータを取得します。プライマリーが未指定の場合はデータが登録されません。
+func (db *DB) Get(model interface{}) error {
+	return db.GetContext(context.Background(), model)
+}
+
+// GetContext でmodelのプライマリーキーでデータを取得します。プライマリーが未指定の場合はデータが登録されません。
+func (db *DB) GetContext(ctx context.Context, model interface{}) error {
+
+	query, err := createSelectQuery(model)
+
 	if err != nil {
-		log.Fatalln(err)
-		db.hasErr = true
-		return nil
+		return err
 	}
 
-	var ret = Table{
-		Rows: make([]Row, 0),
+	_, tp, _ := modelStruct(model)
+
+	if query, err = scopeQuery(ctx, query, tp); err != nil {
+		return err
 	}
 
-	values := make([]sql.NullString, len(columns))
-	scanArgs := make([]interface{}, len(columns))
+	tbl, err := db.QueryContext(ctx, query)
 
-	for i := range values {
-		scanArgs[i] = &values[i]
+	if err != nil {
+		return err
 	}
 
-	for rows.Next() {
+	if len(tbl.Rows) <= 0 {
+		return ErrNotFound
+	}
 
-		err = rows.Scan(scanArgs...)
+	return dbFill(model, &tbl.Rows[0])
+}
 
-		if err != nil {
-			log.Fatalln(err)
-			db.hasErr = true
-			return nil
-		}
+// DBFill はすでに存在するモデルにRowを展開します。プライマリーキーは考慮（再検索）されません。
+// hyudb:"encrypt" のカラムは復号されます。復号に失敗した場合はログを出力し展開しません
+func DBFill(model interface{}, row *Row) {
 
-		cols := make(map[string]string, len(columns))
+	if err := dbFill(model, row); err != nil {
+		log.Println("DBFill : " + err.Error())
+	}
 
-		for i, col := range values {
+}
 
-			cols[columns[i]] = col.String
+// dbFill はRowをモデルに展開します
+// hyudb:"encrypt" のカラムは復号し、hyudb:"json" とsql.Scannerのフィールドは個別に変換します
+func dbFill(model interface{}, row *Row) error {
 
-		}
+	val, tp, err := modelStruct(model)
+
+	if err != nil || !val.CanAddr() {
+		hyutil.ObjFill(model, row.Columns, true)
+		return nil
+	}
+
+	columns := row.Columns
+	copied := false
 
-		var row = Row{
-			Columns: cols,
+	for i := 0; i < tp.NumField(); i++ {
+
+		field := tp.Field(i)
+
+		if field.PkgPath != "" {
+			continue
 		}
 
-		ret.Rows = append(ret.Rows, row)
+		key := field.Tag.Get("hyudb")
+		col := colName(field)
 
-	}
+		v, ok := row.Columns[col]
 
-	return &ret
+		if !ok || (key != tagEncrypt && key != tagJSON && !isScanner(field.Type)) {
+			continue
+		}
 
-}
+		if !copied {
+			// 呼び出し元のRowを書き換えないよう複製する
+			columns = make(map[string]string, len(row.Columns))
+			for k, v := range row.Columns {
+				columns[k] = v
+			}
+			copied = true
+		}
 
-// Get でmodelのプライマリーキーでデータを取得します。プライマリーが未指定の場合はデータが登録されません。
-func (db *DB) Get(model interface{}) error {
+		if key == tagEncrypt {
 
-	query, err := createSelectQuery(model)
+			plain, err := Decrypt(v)
 
-	if err != nil {
-		return err
-	}
+			if err != nil {
+				return fmt.Errorf("%s の復号に失敗しました : %w", col, err)
+			}
 
-	tbl := db.SelectQuery(query)
+			columns[col] = plain
+			continue
+		}
 
-	if len(tbl.Rows) <= 0 {
-		return errors.New("DB.Get:該当するレコードがありません")
+		if err := scanField(field, val.Field(i), v); err != nil {
+			return err
+		}
+
+		delete(columns, col)
 	}
 
-	DBFill(model, &tbl.Rows[0])
+	hyutil.ObjFill(model, columns, true)
 
 	return nil
 }
 
-// DBFill はすでに存在するモデルにRowを展開します。プライマリーキーは考慮（再検索）されません。
-func DBFill(model interface{}, row *Row) {
+func createSelectQuery(model interface{}) (string, error) {
 
-	hyutil.ObjFill(model, row.Columns, true)
+	query, err := createSelectAllQuery(model)
 
-}
+	if err != nil {
+		return "", err
+	}
 
-func createSelectQuery(model interface{}) (string, error) {
+	val, tp, _ := modelStruct(model)
 
-	val := reflect.ValueOf(model)
-	tp := val.Type()
+	pk, ok := modelPK(tp)
 
-	if tp.Kind() == reflect.Ptr {
-		val = val.Elem()
-		tp = tp.Elem()
+	if !ok {
+		return "", errors.New("PrimaryKeyが指定されていません")
 	}
 
-	if tp.Kind() != reflect.Struct {
-		return "", errors.New("引数が構造体ではありません")
-	}
+	keyprm := pkLiteral(val.FieldByName(pk.Name))
 
-	columns := make([]string, 0)
-	pk := ""
+	query += " WHERE " + colName(pk) + " = " + keyprm
 
-	tableName := hyutil.CamelToSnake(tp.Name())
+	return query, nil
+}
 
-	if m, ok := model.(Modeler); ok {
-		tableName = m.TableName()
+// createSelectAllQuery 条件なしでモデルの全カラムを取得するクエリを作成します
+func createSelectAllQuery(model interface{}) (string, error) {
+
+	_, tp, err := modelStruct(model)
+
+	if err != nil {
+		return "", err
 	}
 
-	var pkFieldName string
+	columns := make([]string, 0)
 
 	for i := 0; i < tp.NumField(); i++ {
 
@@ -399,63 +578,110 @@ func createSelectQuery(model interface{}) (string, error) {
 			continue
 		}
 
-		// カラム名作成
-		col := field.Tag.Get("hyudb_col")
-		alias := ""
+		//DB予約文字エスケープ
+		columns = append(columns, ColEsc(colName(field)))
 
-		if col == "" {
-			col = field.Tag.Get("json")
-		} else {
-			// DBFillでhyudb_colが優先されたのでエイリアスは不要に
-			// alias = field.Tag.Get("json")
-		}
+	}
 
-		if col == "" {
-			col = strings.ToLower(hyutil.CamelToSnake(field.Name))
-		}
+	query :=
+		" SELECT " + strings.Join(columns, ",") +
+			" FROM " + modelTableName(model, tp)
 
-		if key == "pk" {
-			pk = col
-			pkFieldName = field.Name
-		}
+	return query, nil
+}
 
-		if alias != "" {
-			col = ColEsc(col) + " AS " + alias
-		} else {
-			col = ColEsc(col)
-		}
+// pkLiteral はプライマリーキー値のクエリ上の表現を返却します
+func pkLiteral(v reflect.Value) string {
 
-		//DB予約文字エスケープ
-		columns = append(columns, col)
+	switch k := v.Interface().(type) {
+	case string:
+		return DbEsc(k)
+	case DBID:
+		return fmt.Sprint(k)
+	default:
+		return fmt.Sprint(k)
+	}
 
+}
+
+// colName はフィールドに対応するカラム名を返却します
+func colName(field reflect.StructField) string {
+
+	col := field.Tag.Get("hyudb_col")
+
+	if col == "" {
+		col = field.Tag.Get("json")
 	}
 
-	if pk == "" {
-		return "", errors.New("PrimaryKeyが指定されていません")
+	if col == "" {
+		col = strings.ToLower(hyutil.CamelToSnake(field.Name))
 	}
 
-	keyprm := ""
-	i := val.FieldByName(pkFieldName)
+	return col
+}
 
-	switch v := i.Interface().(type) {
-	case string:
-		keyprm = DbEsc(v)
-	case DBID:
-		keyprm = fmt.Sprint(v)
-	default:
-		keyprm = fmt.Sprint(i.Interface())
+// modelTableName はモデルのテーブル名を返却します
+func modelTableName(model interface{}, tp reflect.Type) string {
+
+	if m, ok := model.(Modeler); ok {
+		return m.TableName()
 	}
 
-	query :=
-		" SELECT " + strings.Join(columns, ",") +
-			" FROM " + tableName +
-			" WHERE " + pk + " = " + keyprm
+	return hyutil.CamelToSnake(tp.Name())
+}
 
-	return query, nil
+// modelStruct はモデルの構造体の値と型を返却します
+func modelStruct(model interface{}) (reflect.Value, reflect.Type, error) {
+
+	val := reflect.ValueOf(model)
+	tp := val.Type()
+
+	if tp.Kind() == reflect.Ptr {
+		val = val.Elem()
+		tp = tp.Elem()
+	}
+
+	if tp.Kind() != reflect.Struct {
+		return val, tp, errors.New("引数が構造体ではありません")
+	}
+
+	return val, tp, nil
+}
+
+// modelPK はモデルのプライマリーキーのフィールドを返却します
+func modelPK(tp reflect.Type) (reflect.StructField, bool) {
+
+	for i := 0; i < tp.NumField(); i++ {
+
+		field := tp.Field(i)
+
+		if field.Tag.Get("hyudb") == "pk" {
+			return field, true
+		}
+
+	}
+
+	return reflect.StructField{}, false
 }
 
 // Save 要素を作成または更新します
 func (db *DB) Save(model interface{}) error {
+	return db.SaveContext(context.Background(), model)
+}
+
+// SaveContext 要素を作成または更新します。Auditが設定されている場合は変更履歴も記録します
+func (db *DB) SaveContext(ctx context.Context, model interface{}) error {
+
+	if db.Audit == nil {
+		return db.save(ctx, model)
+	}
+
+	return db.auditTx(ctx, func(tx *DB) error {
+		return tx.auditSave(ctx, model)
+	})
+}
+
+func (db *DB) save(ctx context.Context, model interface{}) error {
 
 	val := reflect.ValueOf(model)
 	tp := val.Type()
@@ -503,18 +729,43 @@ func (db *DB) Save(model interface{}) error {
 		return errors.New("プライマーキーの型が不明です。")
 	}
 
+	if err := applyTenant(ctx, val, tp); err != nil {
+		return err
+	}
+
 	if isNew {
-		query := createInsertQuery(model, val, tp)
+		query, err := createInsertQuery(model, val, tp)
+		if err != nil {
+			return err
+		}
 
-		_, id := db.Exec(query)
+		_, id, err := db.ExecContext(ctx, query)
+		if err != nil {
+			return err
+		}
 		if id != NoID {
 			pkVal.SetInt(id)
 		}
 
 	} else {
-		query := createUpdateQuery(model, pkName, fmt.Sprint(pkVal.Interface()), val, tp)
+		query, err := createUpdateQuery(model, pkName, fmt.Sprint(pkVal.Interface()), val, tp)
+		if err != nil {
+			return err
+		}
+
+		if query, err = scopeQuery(ctx, query, tp); err != nil {
+			return err
+		}
+
+		affected, _, err := db.ExecContext(ctx, query)
 
-		db.Exec(query)
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return db.checkTenantRow(ctx, model, tp)
+		}
 	}
 
 	return nil
@@ -525,7 +776,7 @@ const (
 	mapUpd
 )
 
-func createInsertQuery(model interface{}, val reflect.Value, tp reflect.Type) string {
+func createInsertQuery(model interface{}, val reflect.Value, tp reflect.Type) (string, error) {
 
 	columns := make([]string, 0)
 	vals := make([]string, 0)
@@ -536,7 +787,10 @@ func createInsertQuery(model interface{}, val reflect.Value, tp reflect.Type) st
 		tableName = m.TableName()
 	}
 
-	colVal := createColValMap(val, tp, mapIns)
+	colVal, err := createColValMap(val, tp, mapIns)
+	if err != nil {
+		return "", err
+	}
 
 	// カラム名と値文字列の順番を揃える
 	for k, v := range colVal {
@@ -551,11 +805,11 @@ func createInsertQuery(model interface{}, val reflect.Value, tp reflect.Type) st
 			strings.Join(vals, ",") +
 			" ) "
 
-	return query
+	return query, nil
 
 }
 
-func createUpdateQuery(model interface{}, pkName string, pkVal string, val reflect.Value, tp reflect.Type) string {
+func createUpdateQuery(model interface{}, pkName string, pkVal string, val reflect.Value, tp reflect.Type) (string, error) {
 
 	sets := make([]string, 0)
 
@@ -565,7 +819,10 @@ func createUpdateQuery(model interface{}, pkName string, pkVal string, val refle
 		tableName = m.TableName()
 	}
 
-	colVal := createColValMap(val, tp, mapUpd)
+	colVal, err := createColValMap(val, tp, mapUpd)
+	if err != nil {
+		return "", err
+	}
 
 	// カラム名と値文字列の順番を揃える
 	for k, v := range colVal {
@@ -577,10 +834,10 @@ func createUpdateQuery(model interface{}, pkName string, pkVal string, val refle
 			strings.Join(sets, ",") +
 			" WHERE " + ColEsc(pkName) + " = " + pkVal
 
-	return query
+	return query, nil
 }
 
-func createColValMap(val reflect.Value, tp reflect.Type, mode int) map[string]string {
+func createColValMap(val reflect.Value, tp reflect.Type, mode int) (map[string]string, error) {
 
 	ret := make(map[string]string)
 
@@ -611,6 +868,23 @@ func createColValMap(val reflect.Value, tp reflect.Type, mode int) map[string]st
 		//DB予約文字エスケープ
 		col = ColEsc(col)
 
+		if key == tagEncrypt {
+			enc, err := encryptField(val.FieldByName(field.Name))
+			if err != nil {
+				return nil, err
+			}
+			ret[col] = DbEsc(enc)
+			continue
+		}
+
+		if dv, ok, err := driverValue(field, val.FieldByName(field.Name)); ok {
+			if err != nil {
+				return nil, err
+			}
+			ret[col] = driverValueLiteral(dv)
+			continue
+		}
+
 		switch v := val.FieldByName(field.Name).Interface().(type) {
 		case string:
 			ret[col] = DbEsc(v)
@@ -640,15 +914,61 @@ func createColValMap(val reflect.Value, tp reflect.Type, mode int) map[string]st
 
 	}
 
-	return ret
+	return ret, nil
 }
 
 // Del 要素を論理削除します TODO
+// 物理削除する場合はDelForeverを使用してください
 func (db *DB) Del(model interface{}) error {
 
 	return nil
 }
 
+// DelForever 要素をプライマリーキーで物理削除します
+func (db *DB) DelForever(model interface{}) error {
+	return db.DelForeverContext(context.Background(), model)
+}
+
+// DelForeverContext 要素をプライマリーキーで物理削除します。Auditが設定されている場合は変更履歴も記録します
+func (db *DB) DelForeverContext(ctx context.Context, model interface{}) error {
+
+	if db.Audit == nil {
+		return db.del(ctx, model)
+	}
+
+	return db.auditTx(ctx, func(tx *DB) error {
+		return tx.auditDel(ctx, model)
+	})
+}
+
+func (db *DB) del(ctx context.Context, model interface{}) error {
+
+	query, err := createDeleteForeverQuery(model)
+
+	if err != nil {
+		return err
+	}
+
+	_, tp, _ := modelStruct(model)
+
+	if query, err = scopeQuery(ctx, query, tp); err != nil {
+		return err
+	}
+
+	affected, _, err := db.ExecContext(ctx, query)
+
+	if err != nil {
+		return err
+	}
+
+	// 他のテナントの行は削除されない
+	if _, ok := modelTenant(tp); ok && affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
 func createDeleteQuery(model interface{}) (string, error) {
 
 	return "", nil
@@ -656,5 +976,21 @@ func createDeleteQuery(model interface{}) (string, error) {
 
 func createDeleteForeverQuery(model interface{}) (string, error) {
 
-	return "", nil
+	val, tp, err := modelStruct(model)
+
+	if err != nil {
+		return "", err
+	}
+
+	pk, ok := modelPK(tp)
+
+	if !ok {
+		return "", errors.New("プライマリーキーの指定がありません")
+	}
+
+	query :=
+		" DELETE FROM " + modelTableName(model, tp) +
+			" WHERE " + ColEsc(colName(pk)) + " = " + pkLiteral(val.FieldByName(pk.Name))
+
+	return query, nil
 }
@@ -1,10 +1,12 @@
 package hyudb_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/gara-snake/hyutil"
 	"github.com/gara-snake/hyutil/hyudb"
+	"github.com/gara-snake/hyutil/hyudb/fixtures"
 
 	"github.com/cheekybits/is"
 
@@ -27,11 +29,26 @@ func (t *TestObj) TableName() string {
 	return "test"
 }
 
+func loadFixtures(t *testing.T, db *hyudb.DB) {
+
+	l := fixtures.New(db)
+
+	if err := l.LoadFiles("testdata/fixtures.yml"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+}
+
 func TestSelect(t *testing.T) {
 
 	is := is.New(t)
 
 	db := hyudb.MysqlNew(connectionString)
+	loadFixtures(t, db)
 
 	tbl := db.SelectQuery(" SELECT * FROM hr_employee ")
 
@@ -45,6 +62,7 @@ func TestGet(t *testing.T) {
 	is := is.New(t)
 
 	db := hyudb.MysqlNew(connectionString)
+	loadFixtures(t, db)
 
 	obj := &TestObj{
 		ID: 1,
@@ -64,3 +82,63 @@ func TestGet(t *testing.T) {
 	is.Equal("2018-10-26 14:24:06", obj.UpdDate.Format("2006-01-02 15:04:05"))
 
 }
+
+func TestEach(t *testing.T) {
+
+	is := is.New(t)
+
+	db := hyudb.MysqlNew(connectionString)
+	loadFixtures(t, db)
+
+	count := 0
+
+	err := hyudb.Each(db, " SELECT * FROM test WHERE id = ? ", func(obj *TestObj) error {
+		count++
+		is.Equal("テスト太郎", obj.Name)
+		return hyudb.ErrStopIteration
+	}, 1)
+
+	is.NoErr(err)
+	is.Equal(1, count)
+
+}
+
+func TestRepoFind(t *testing.T) {
+
+	is := is.New(t)
+
+	db := hyudb.MysqlNew(connectionString)
+	loadFixtures(t, db)
+
+	repo, err := hyudb.NewRepo[*TestObj](db)
+	is.NoErr(err)
+
+	obj, err := repo.Find(context.Background(), 1)
+
+	is.NoErr(err)
+	is.Equal("テスト太郎", obj.Name)
+
+	_, err = repo.Find(context.Background(), -1)
+
+	is.Equal(hyudb.ErrNotFound, err)
+
+}
+
+type noPKObj struct {
+	Name string
+}
+
+func (o *noPKObj) TableName() string {
+	return "no_pk"
+}
+
+func TestNewRepoError(t *testing.T) {
+
+	is := is.New(t)
+
+	_, err := hyudb.NewRepo[*noPKObj](nil)
+	is.Err(err)
+
+	_, err = hyudb.NewRepo[hyudb.Modeler](nil)
+	is.Err(err)
+}
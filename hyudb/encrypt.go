@@ -0,0 +1,330 @@
+package hyudb
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// tagEncrypt は暗号化して保存するカラムのタグ値です
+//
+//	Tel string `hyudb:"encrypt"`
+const tagEncrypt = "encrypt"
+
+// 暗号化された値の接頭辞 "enc:<キーID>:<base64(nonce+暗号文)>"
+const encPrefix = "enc:"
+
+// KeyProvider は暗号化キーの取得元です
+// キーはAES-128/192/256に対応する16、24、32バイトである必要があります
+type KeyProvider interface {
+	// CurrentKey 暗号化に使用する現在のキーを返却します
+	CurrentKey() (id string, key []byte, err error)
+	// Key 復号に使用するキーをIDで返却します
+	Key(id string) ([]byte, error)
+}
+
+// StaticKeys は固定のキーを保持するKeyProviderです
+type StaticKeys struct {
+	// Current 暗号化に使用するキーのID
+	Current string
+	// Keys キーIDごとのキー。ローテーション後も復号のため古いキーを残してください
+	Keys map[string][]byte
+}
+
+// CurrentKey 現在のキーを返却します
+func (sk *StaticKeys) CurrentKey() (string, []byte, error) {
+	key, err := sk.Key(sk.Current)
+	return sk.Current, key, err
+}
+
+// Key IDに対応するキーを返却します
+func (sk *StaticKeys) Key(id string) ([]byte, error) {
+
+	key, ok := sk.Keys[id]
+
+	if !ok {
+		return nil, errors.New("暗号化キーが見つかりません : " + id)
+	}
+
+	return key, nil
+}
+
+var keyProvider KeyProvider
+
+// SetKeyProvider は hyudb:"encrypt" カラムの暗号化キーの取得元を設定します
+func SetKeyProvider(kp KeyProvider) {
+	keyProvider = kp
+}
+
+// Encrypt は文字列をAES-GCMで暗号化します。空文字列はそのまま返却します
+func Encrypt(plain string) (string, error) {
+
+	if plain == "" {
+		return "", nil
+	}
+
+	if keyProvider == nil {
+		return "", errors.New("KeyProviderが設定されていません")
+	}
+
+	id, key, err := keyProvider.CurrentKey()
+
+	if err != nil {
+		return "", err
+	}
+
+	if strings.Contains(id, ":") {
+		return "", errors.New("キーIDに':'は使用できません : " + id)
+	}
+
+	gcm, err := newGCM(key)
+
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+
+	return encPrefix + id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// ErrNotEncrypted は hyudb:"encrypt" のカラムの値が暗号化されていない場合のエラーです
+var ErrNotEncrypted = errors.New("暗号化された値ではありません")
+
+// Decrypt はEncryptで暗号化された文字列を復号します。空文字列はそのまま返却します
+// 値から平文かどうかを推測しないため、暗号化されていない値はErrNotEncryptedになります
+// hyudb:"encrypt" を指定する前に登録された値はReEncryptで暗号化してください
+func Decrypt(s string) (string, error) {
+
+	if s == "" {
+		return "", nil
+	}
+
+	id, data, ok := splitEncrypted(s)
+
+	if !ok {
+		return "", ErrNotEncrypted
+	}
+
+	if keyProvider == nil {
+		return "", errors.New("KeyProviderが設定されていません")
+	}
+
+	key, err := keyProvider.Key(id)
+
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(key)
+
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(data)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("暗号化された値が不正です")
+	}
+
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// splitEncrypted 暗号化された値をキーIDと本体に分割します
+func splitEncrypted(s string) (string, string, bool) {
+
+	if !strings.HasPrefix(s, encPrefix) {
+		return "", "", false
+	}
+
+	return strings.Cut(s[len(encPrefix):], ":")
+}
+
+func encryptField(v reflect.Value) (string, error) {
+
+	if v.Kind() != reflect.String {
+		return "", errors.New("暗号化できるのは文字列型のみです : " + v.Type().String())
+	}
+
+	return Encrypt(v.String())
+}
+
+// encryptedColumns hyudb:"encrypt" が指定されたフィールドのカラム名を返却します
+func encryptedColumns(tp reflect.Type) []string {
+
+	ret := make([]string, 0)
+
+	for i := 0; i < tp.NumField(); i++ {
+
+		field := tp.Field(i)
+
+		if field.Tag.Get("hyudb") == tagEncrypt {
+			ret = append(ret, colName(field))
+		}
+
+	}
+
+	return ret
+}
+
+// ReEncrypt はmodelのテーブルを走査し、現在のキー以外で暗号化されたカラムを現在のキーで暗号化し直します
+// hyudb:"encrypt" を指定する前に登録された暗号化されていない値も暗号化します。更新した行数を返却します
+// "enc:"で始まる値は暗号化済みとして復号するため、復号できない場合はエラーになります
+func (db *DB) ReEncrypt(ctx context.Context, model interface{}) (int, error) {
+
+	_, tp, err := modelStruct(model)
+
+	if err != nil {
+		return 0, err
+	}
+
+	pk, ok := modelPK(tp)
+
+	if !ok {
+		return 0, errors.New("プライマリーキーの指定がありません")
+	}
+
+	cols := encryptedColumns(tp)
+
+	if len(cols) == 0 {
+		return 0, nil
+	}
+
+	if keyProvider == nil {
+		return 0, errors.New("KeyProviderが設定されていません")
+	}
+
+	current, _, err := keyProvider.CurrentKey()
+
+	if err != nil {
+		return 0, err
+	}
+
+	table := modelTableName(model, tp)
+	pkCol := colName(pk)
+
+	selCols := []string{ColEsc(pkCol)}
+	for _, c := range cols {
+		selCols = append(selCols, ColEsc(c))
+	}
+
+	query := " SELECT " + strings.Join(selCols, ",") + " FROM " + table
+	req := PageRequest{Size: 500, KeyColumn: pkCol, NoCount: true}
+
+	count := 0
+
+	for {
+
+		pageQuery, args := createPageQuery(query, &req, nil)
+
+		// 同一接続で読み込み中に更新できないため1ページずつ読み切ってから更新する
+		rows := make([]Row, 0, req.Size+1)
+
+		err := db.IterateContext(ForcePrimary(ctx), pageQuery, func(r Row) error {
+			rows = append(rows, r)
+			return nil
+		}, args...)
+
+		if err != nil {
+			return count, err
+		}
+
+		if len(rows) == 0 {
+			return count, nil
+		}
+
+		if req.Size < len(rows) {
+			rows = rows[:req.Size]
+		}
+
+		for _, r := range rows {
+
+			sets := make([]string, 0)
+			vals := make([]interface{}, 0)
+
+			for _, c := range cols {
+
+				id, _, ok := splitEncrypted(r.Columns[c])
+
+				if id == current || r.Columns[c] == "" {
+					continue
+				}
+
+				// 暗号化されていない値は導入前の平文として暗号化する
+				plain := r.Columns[c]
+
+				if ok {
+					if plain, err = Decrypt(r.Columns[c]); err != nil {
+						return count, fmt.Errorf("%s.%s (%s) の復号に失敗しました : %w", table, c, r.Columns[pkCol], err)
+					}
+				}
+
+				enc, err := Encrypt(plain)
+
+				if err != nil {
+					return count, err
+				}
+
+				sets = append(sets, ColEsc(c)+" = ?")
+				vals = append(vals, enc)
+			}
+
+			if len(sets) == 0 {
+				continue
+			}
+
+			vals = append(vals, r.Columns[pkCol])
+
+			_, _, err := db.ExecContext(ctx,
+				" UPDATE "+table+" SET "+strings.Join(sets, ",")+" WHERE "+ColEsc(pkCol)+" = ?", vals...)
+
+			if err != nil {
+				return count, err
+			}
+
+			count++
+		}
+
+		req.After = rows[len(rows)-1].Columns[pkCol]
+
+		if len(rows) < req.Size {
+			return count, nil
+		}
+
+	}
+
+}
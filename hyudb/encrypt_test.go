@@ -0,0 +1,95 @@
+package hyudb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+)
+
+type encObj struct {
+	ID   DBID `hyudb:"pk"`
+	Name string
+	Tel  string `hyudb:"encrypt"`
+}
+
+func TestEncrypt(t *testing.T) {
+
+	is := is.New(t)
+
+	keys := &StaticKeys{
+		Current: "k1",
+		Keys: map[string][]byte{
+			"k1": []byte("0123456789abcdef0123456789abcdef"),
+			"k2": []byte("fedcba9876543210fedcba9876543210"),
+		},
+	}
+
+	SetKeyProvider(keys)
+	defer SetKeyProvider(nil)
+
+	enc1, err := Encrypt("090-1234-5678")
+	is.NoErr(err)
+	is.True(strings.HasPrefix(enc1, "enc:k1:"))
+
+	// キーを切り替えても古いキーの値は復号できる
+	keys.Current = "k2"
+
+	enc2, err := Encrypt("090-1234-5678")
+	is.NoErr(err)
+	is.True(strings.HasPrefix(enc2, "enc:k2:"))
+
+	for _, enc := range []string{enc1, enc2} {
+		plain, err := Decrypt(enc)
+		is.NoErr(err)
+		is.Equal("090-1234-5678", plain)
+	}
+
+	// 値から平文を推測しない
+	_, err = Decrypt("090-1234-5678")
+	is.Equal(ErrNotEncrypted, err)
+
+	_, err = Decrypt("enc:memo")
+	is.Err(err)
+
+	plain, err := Decrypt("")
+	is.NoErr(err)
+	is.Equal("", plain)
+
+	delete(keys.Keys, "k1")
+	_, err = Decrypt(enc1)
+	is.Err(err)
+
+}
+
+func TestEncryptColumn(t *testing.T) {
+
+	is := is.New(t)
+
+	SetKeyProvider(&StaticKeys{
+		Current: "k1",
+		Keys:    map[string][]byte{"k1": []byte("0123456789abcdef")},
+	})
+	defer SetKeyProvider(nil)
+
+	obj := &encObj{ID: 1, Name: "テスト太郎", Tel: "090-1234-5678"}
+	val := reflect.ValueOf(obj).Elem()
+
+	cols, err := createColValMap(val, val.Type(), mapUpd)
+	is.NoErr(err)
+	is.Equal("'テスト太郎'", cols["`name`"])
+	is.True(strings.HasPrefix(cols["`tel`"], "'enc:k1:"))
+
+	row := &Row{Columns: map[string]string{
+		"id":   "1",
+		"name": "テスト太郎",
+		"tel":  strings.Trim(cols["`tel`"], "'"),
+	}}
+
+	filled := &encObj{}
+	is.NoErr(dbFill(filled, row))
+	is.Equal("090-1234-5678", filled.Tel)
+	is.True(strings.HasPrefix(row.Columns["tel"], "enc:k1:"))
+
+}
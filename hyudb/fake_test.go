@@ -0,0 +1,12 @@
+package hyudb
+
+import (
+	"database/sql"
+
+	"github.com/gara-snake/hyutil/internal/hyudbtest"
+)
+
+// newFakeDB はテスト用のドライバーの参照を返却します
+func newFakeDB(c *hyudbtest.Connector) *DB {
+	return &DB{IsOpen: true, connection: sql.OpenDB(c)}
+}
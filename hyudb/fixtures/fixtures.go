@@ -0,0 +1,296 @@
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gara-snake/hyutil/hyucsv"
+	"github.com/gara-snake/hyutil/hyudb"
+	"gopkg.in/yaml.v3"
+)
+
+// Record カラム名ごとの値
+type Record map[string]interface{}
+
+// Loader テーブル名ごとのテストデータを保持してデータベースに登録します
+type Loader struct {
+	db     *hyudb.DB
+	tables map[string][]Record
+}
+
+// New Loaderを作成します
+func New(db *hyudb.DB) *Loader {
+	return &Loader{
+		db:     db,
+		tables: make(map[string][]Record),
+	}
+}
+
+// Add テーブルに登録する行を追加します
+func (l *Loader) Add(table string, rows ...Record) *Loader {
+	l.tables[table] = append(l.tables[table], rows...)
+	return l
+}
+
+// AddCsv hyucsv.Csvの行をテーブルに登録する行として追加します。CsvFieldのKeyをカラム名とします
+// 空文字列と"NULL"はNULLとして登録されます
+func (l *Loader) AddCsv(table string, c *hyucsv.Csv) *Loader {
+
+	for _, row := range c.Rows {
+
+		rec := make(Record)
+
+		for _, f := range c.Fields {
+			rec[f.Key] = csvValue(row[f.Key])
+		}
+
+		l.Add(table, rec)
+	}
+
+	return l
+}
+
+// Tables 登録対象のテーブル名を返却します
+func (l *Loader) Tables() []string {
+
+	ret := make([]string, 0, len(l.tables))
+
+	for t := range l.tables {
+		ret = append(ret, t)
+	}
+
+	sort.Strings(ret)
+
+	return ret
+}
+
+// LoadFiles ファイルからテストデータを読み込みます
+//
+// .yml .yaml .json はテーブル名をキーとした行の配列です
+//
+//	test:
+//	  - id: 1
+//	    name: テスト太郎
+//
+// .csv はファイル名をテーブル名とし、1行目をカラム名として扱います
+func (l *Loader) LoadFiles(paths ...string) error {
+
+	for _, p := range paths {
+
+		b, err := os.ReadFile(p)
+
+		if err != nil {
+			return err
+		}
+
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".yml", ".yaml":
+			err = l.addYAML(b)
+		case ".json":
+			err = l.addJSON(b)
+		case ".csv":
+			err = l.addCSV(strings.TrimSuffix(filepath.Base(p), filepath.Ext(p)), b)
+		default:
+			err = errors.New("対応していないファイル形式です")
+		}
+
+		if err != nil {
+			return errors.New(p + " : " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (l *Loader) addYAML(b []byte) error {
+
+	data := make(map[string][]Record)
+
+	if err := yaml.Unmarshal(b, &data); err != nil {
+		return err
+	}
+
+	for t, rows := range data {
+		l.Add(t, rows...)
+	}
+
+	return nil
+}
+
+func (l *Loader) addJSON(b []byte) error {
+
+	data := make(map[string][]Record)
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	if err := dec.Decode(&data); err != nil {
+		return err
+	}
+
+	for t, rows := range data {
+		l.Add(t, rows...)
+	}
+
+	return nil
+}
+
+func (l *Loader) addCSV(table string, b []byte) error {
+
+	b = bytes.TrimPrefix(b, []byte{0xEF, 0xBB, 0xBF})
+
+	records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+
+	if err != nil {
+		return err
+	}
+
+	if len(records) < 1 {
+		return nil
+	}
+
+	header := records[0]
+
+	for _, r := range records[1:] {
+
+		rec := make(Record)
+
+		for i, h := range header {
+			if i < len(r) {
+				rec[strings.TrimSpace(h)] = csvValue(r[i])
+			}
+		}
+
+		l.Add(table, rec)
+	}
+
+	return nil
+}
+
+func csvValue(s string) interface{} {
+	if s == "" || s == "NULL" {
+		return nil
+	}
+	return s
+}
+
+// Load 対象のテーブルを空にしてからテストデータを1つのトランザクションで登録します
+func (l *Loader) Load(ctx context.Context) error {
+
+	return withoutFKChecks(ctx, l.db, func(conn *sql.Conn) error {
+
+		if err := truncate(ctx, conn, l.Tables()); err != nil {
+			return err
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+
+		if err != nil {
+			return err
+		}
+
+		for _, t := range l.Tables() {
+			for _, rec := range l.tables[t] {
+				if err := insert(ctx, tx, t, rec); err != nil {
+					tx.Rollback()
+					return err
+				}
+			}
+		}
+
+		return tx.Commit()
+	})
+}
+
+func insert(ctx context.Context, tx *sql.Tx, table string, rec Record) error {
+
+	cols := make([]string, 0, len(rec))
+
+	for c := range rec {
+		cols = append(cols, c)
+	}
+
+	sort.Strings(cols)
+
+	names := make([]string, len(cols))
+	holders := make([]string, len(cols))
+	args := make([]interface{}, len(cols))
+
+	for i, c := range cols {
+		names[i] = hyudb.ColEsc(c)
+		holders[i] = "?"
+		args[i] = rec[c]
+	}
+
+	query :=
+		" INSERT INTO " + hyudb.ColEsc(table) + " (" + strings.Join(names, ",") + ") " +
+			" VALUES (" + strings.Join(holders, ",") + ") "
+
+	_, err := tx.ExecContext(ctx, query, args...)
+
+	return err
+}
+
+// Truncate 外部キー制約を無効にしてテーブルを空にします
+func Truncate(ctx context.Context, db *hyudb.DB, tables ...string) error {
+
+	if len(tables) == 0 {
+		return nil
+	}
+
+	return withoutFKChecks(ctx, db, func(conn *sql.Conn) error {
+		return truncate(ctx, conn, tables)
+	})
+}
+
+func truncate(ctx context.Context, conn *sql.Conn, tables []string) error {
+
+	for _, t := range tables {
+		if _, err := conn.ExecContext(ctx, "TRUNCATE TABLE "+hyudb.ColEsc(t)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// withoutFKChecks 接続を固定して外部キー制約を無効にし、fnの終了後はエラーの有無にかかわらず有効に戻します
+// SET FOREIGN_KEY_CHECKSは接続ごとの設定のため、戻せなかった接続は接続プールに返却せずに破棄します
+func withoutFKChecks(ctx context.Context, db *hyudb.DB, fn func(conn *sql.Conn) error) (err error) {
+
+	conn, err := db.Conn(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return err
+	}
+
+	defer func() {
+
+		// ctxがキャンセルされていても戻す
+		if _, rerr := conn.ExecContext(context.Background(), "SET FOREIGN_KEY_CHECKS = 1"); rerr != nil {
+
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+
+			if err == nil {
+				err = rerr
+			}
+		}
+	}()
+
+	return fn(conn)
+}
@@ -0,0 +1,120 @@
+package fixtures
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/gara-snake/hyutil/hyucsv"
+	"github.com/gara-snake/hyutil/hyudb"
+	"github.com/gara-snake/hyutil/internal/hyudbtest"
+)
+
+func TestLoadFiles(t *testing.T) {
+
+	is := is.New(t)
+
+	l := New(nil)
+
+	err := l.LoadFiles("testdata/test.yml", "testdata/hr_employee.csv", "testdata/orders.json")
+	is.NoErr(err)
+
+	is.Equal([]string{"hr_employee", "orders", "test"}, l.Tables())
+
+	is.Equal("テスト太郎", l.tables["test"][0]["name"])
+	is.Equal(15, l.tables["test"][0]["age"])
+
+	is.Equal("大志", l.tables["hr_employee"][0]["first_name"])
+	is.Nil(l.tables["hr_employee"][0]["last_name"])
+
+	is.Equal(2, len(l.tables["orders"]))
+	is.Equal(json.Number("1200"), l.tables["orders"][0]["amount"])
+	is.Nil(l.tables["orders"][1]["amount"])
+
+	is.Err(l.LoadFiles("testdata/none.txt"))
+
+}
+
+func TestAddCsv(t *testing.T) {
+
+	is := is.New(t)
+
+	c := &hyucsv.Csv{
+		Fields: []hyucsv.CsvField{{Key: "id", Label: "ID"}, {Key: "name", Label: "名前"}},
+		Rows:   []hyucsv.CsvRow{{"id": "1", "name": "テスト太郎"}, {"id": "2", "name": ""}},
+	}
+
+	l := New(nil).AddCsv("test", c)
+
+	is.Equal(2, len(l.tables["test"]))
+	is.Equal("テスト太郎", l.tables["test"][0]["name"])
+	is.Nil(l.tables["test"][1]["name"])
+
+}
+
+var fake = &hyudbtest.Connector{}
+
+func init() {
+	hyudbtest.Register("fixtures_fake", fake)
+}
+
+// failOn prefixで始まるクエリをエラーにします
+func failOn(prefix string) {
+	fake.Exec = func(query string, args []driver.NamedValue) (driver.Result, error) {
+		if strings.HasPrefix(strings.TrimSpace(query), prefix) {
+			return nil, errors.New("fail : " + prefix)
+		}
+		return hyudbtest.Result{Affected: 1}, nil
+	}
+}
+
+// execQueries 実行されたクエリ。コミットとロールバックは除きます
+func execQueries() []string {
+
+	var ret []string
+
+	for _, q := range fake.Queries() {
+		if q != "COMMIT" && q != "ROLLBACK" {
+			ret = append(ret, strings.TrimSpace(q))
+		}
+	}
+
+	return ret
+}
+
+func TestLoadRestoresForeignKeyChecks(t *testing.T) {
+
+	is := is.New(t)
+
+	db := hyudb.New("fixtures_fake", "")
+	defer db.Close()
+	defer func() { fake.Exec = nil }()
+
+	l := New(db).Add("order", Record{"id": 1})
+
+	failOn("TRUNCATE")
+	fake.Reset()
+
+	is.Err(l.Load(context.Background()))
+	is.Equal([]string{"SET FOREIGN_KEY_CHECKS = 0", "TRUNCATE TABLE `order`", "SET FOREIGN_KEY_CHECKS = 1"}, execQueries())
+
+	failOn("INSERT")
+	fake.Reset()
+
+	is.Err(l.Load(context.Background()))
+	queries := execQueries()
+	is.Equal("SET FOREIGN_KEY_CHECKS = 1", queries[len(queries)-1])
+	is.True(strings.HasPrefix(queries[2], "INSERT INTO `order`"))
+
+	// 戻せなかった接続は破棄する
+	failOn("SET FOREIGN_KEY_CHECKS = 1")
+	closed := fake.Closed()
+
+	is.Err(Truncate(context.Background(), db, "order"))
+	is.Equal(closed+1, fake.Closed())
+
+}
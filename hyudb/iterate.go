@@ -0,0 +1,114 @@
+package hyudb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+)
+
+// ErrStopIteration はIterate、Eachの関数から返却するとエラーとせずに読み込みを終了します
+var ErrStopIteration = errors.New("hyudb: stop iteration")
+
+// Iterate queryを実行し、1行ずつfnを呼び出します。結果全体をメモリに展開しません
+// fnがエラーを返却した場合は読み込みを中断し、そのエラーを返却します
+func (db *DB) Iterate(query string, fn func(r Row) error, args ...interface{}) error {
+	return db.IterateContext(context.Background(), query, fn, args...)
+}
+
+// IterateContext queryを実行し、1行ずつfnを呼び出します。結果全体をメモリに展開しません
+func (db *DB) IterateContext(ctx context.Context, query string, fn func(r Row) error, args ...interface{}) error {
+
+	if db.Debug {
+		log.Println("SELECT QUERY : " + query)
+	}
+
+	rows, err := db.readQuery(ctx, query, args...)
+
+	if err != nil {
+		return db.readErr(query, err)
+	}
+
+	defer rows.Close()
+
+	// fnのエラーはクエリのエラーとして扱わない
+	var fnErr error
+
+	err = scanRows(rows, func(r Row) error {
+		fnErr = fn(r)
+		return fnErr
+	})
+
+	if err == nil {
+		return nil
+	}
+
+	if err != fnErr {
+		return db.readErr(query, err)
+	}
+
+	if errors.Is(err, ErrStopIteration) {
+		return nil
+	}
+
+	return err
+}
+
+// Each queryを実行し、1行ずつ構造体にDBFillしてfnを呼び出します
+// 構造体は行ごとにゼロ値に戻して再利用されるため、fnの外で保持する場合はコピーしてください
+func Each[T any](db *DB, query string, fn func(m *T) error, args ...interface{}) error {
+	return EachContext(context.Background(), db, query, fn, args...)
+}
+
+// EachContext queryを実行し、1行ずつ構造体にDBFillしてfnを呼び出します
+func EachContext[T any](ctx context.Context, db *DB, query string, fn func(m *T) error, args ...interface{}) error {
+
+	var m T
+	var zero T
+
+	return db.IterateContext(ctx, query, func(r Row) error {
+		m = zero
+		if err := dbFill(&m, &r); err != nil {
+			return err
+		}
+		return fn(&m)
+	}, args...)
+}
+
+// scanRows rowsを1行ずつRowに変換してfnを呼び出します
+func scanRows(rows *sql.Rows, fn func(r Row) error) error {
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	values := make([]sql.NullString, len(columns))
+	scanArgs := make([]interface{}, len(columns))
+
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	for rows.Next() {
+
+		if err := rows.Scan(scanArgs...); err != nil {
+			return err
+		}
+
+		cols := make(map[string]string, len(columns))
+
+		for i, col := range values {
+
+			cols[columns[i]] = col.String
+
+		}
+
+		if err := fn(Row{Columns: cols}); err != nil {
+			return err
+		}
+
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,144 @@
+package hyudb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// tagJSON はJSONに変換して保存するカラムのタグ値です。構造体、map、スライスのフィールドに指定します
+//
+//	Options map[string]string `hyudb:"json"`
+const tagJSON = "json"
+
+var (
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// isScanner フィールドの型がsql.Scannerを実装しているか
+func isScanner(tp reflect.Type) bool {
+	return reflect.PointerTo(tp).Implements(scannerType)
+}
+
+// driverValue hyudb:"json" またはdriver.Valuerのフィールドを保存用の値に変換します
+// 対象外のフィールドの場合はokがfalseになります
+func driverValue(field reflect.StructField, v reflect.Value) (ret driver.Value, ok bool, err error) {
+
+	if field.Tag.Get("hyudb") == tagJSON {
+
+		if (v.Kind() == reflect.Map || v.Kind() == reflect.Slice || v.Kind() == reflect.Ptr) && v.IsNil() {
+			return nil, true, nil
+		}
+
+		b, err := json.Marshal(v.Interface())
+
+		if err != nil {
+			return nil, true, fmt.Errorf("%s のJSON変換に失敗しました : %w", field.Name, err)
+		}
+
+		return string(b), true, nil
+	}
+
+	if field.Type.Implements(valuerType) {
+
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, true, nil
+		}
+
+		ret, err := v.Interface().(driver.Valuer).Value()
+
+		if err != nil {
+			return nil, true, fmt.Errorf("%s の変換に失敗しました : %w", field.Name, err)
+		}
+
+		return ret, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// driverValueLiteral driver.Valueのクエリ上の表現を返却します
+func driverValueLiteral(v driver.Value) string {
+
+	switch d := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return strLiteral(d)
+	case []byte:
+		return strLiteral(string(d))
+	case bool:
+		return DbBool(d)
+	case time.Time:
+		return "'" + d.Format(dbDatetimeFormat) + "'"
+	default:
+		return fmt.Sprint(d)
+	}
+
+}
+
+// strLiteral 文字列のクエリ上の表現を返却します。空文字列はNULLです
+// DbEscと異なり"\"もエスケープするため、JSONの"\n"や"\u003c"などがMySQLで解釈されずに保存されます
+func strLiteral(s string) string {
+
+	if s == "" {
+		return "NULL"
+	}
+
+	return DbEsc(strings.ReplaceAll(s, "\\", "\\\\"))
+}
+
+// driverValueString driver.Valueの文字列表現を返却します。NULLは空文字列です
+func driverValueString(v driver.Value) string {
+
+	switch d := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(d)
+	case bool:
+		return DbBool(d)
+	case time.Time:
+		return d.Format(dbDatetimeFormat)
+	default:
+		return fmt.Sprint(d)
+	}
+
+}
+
+// scanField hyudb:"json" またはsql.Scannerのフィールドにカラムの値を設定します
+// NULLと空文字列は区別できないため、空文字列はNULLとして扱います
+func scanField(field reflect.StructField, dest reflect.Value, s string) error {
+
+	if field.Tag.Get("hyudb") == tagJSON {
+
+		dest.Set(reflect.Zero(field.Type))
+
+		if s == "" {
+			return nil
+		}
+
+		if err := json.Unmarshal([]byte(s), dest.Addr().Interface()); err != nil {
+			return fmt.Errorf("%s のJSON解析に失敗しました : %w", field.Name, err)
+		}
+
+		return nil
+	}
+
+	var src interface{}
+
+	if s != "" {
+		src = s
+	}
+
+	if err := dest.Addr().Interface().(sql.Scanner).Scan(src); err != nil {
+		return fmt.Errorf("%s の変換に失敗しました : %w", field.Name, err)
+	}
+
+	return nil
+}
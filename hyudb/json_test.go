@@ -0,0 +1,127 @@
+package hyudb
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+)
+
+type jsonAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+type jsonObj struct {
+	ID      DBID              `hyudb:"pk"`
+	Address jsonAddress       `hyudb:"json"`
+	Tags    []string          `hyudb:"json"`
+	Options map[string]string `hyudb:"json"`
+	Nick    sql.NullString
+}
+
+func TestJSONColumn(t *testing.T) {
+
+	is := is.New(t)
+
+	obj := &jsonObj{
+		ID:      1,
+		Address: jsonAddress{City: "東京", Zip: "100-0001"},
+		Tags:    []string{"a", "b"},
+		Nick:    sql.NullString{String: "たろう", Valid: true},
+	}
+
+	val := reflect.ValueOf(obj).Elem()
+
+	cols, err := createColValMap(val, val.Type(), mapUpd)
+	is.NoErr(err)
+	is.Equal(`'{"city":"東京","zip":"100-0001"}'`, cols["`address`"])
+	is.Equal(`'["a","b"]'`, cols["`tags`"])
+	is.Equal("NULL", cols["`options`"])
+	is.Equal("'たろう'", cols["`nick`"])
+
+	row := &Row{Columns: map[string]string{
+		"id":      "1",
+		"address": `{"city":"東京","zip":"100-0001"}`,
+		"tags":    `["a","b"]`,
+		"options": "",
+		"nick":    "",
+	}}
+
+	filled := &jsonObj{Nick: sql.NullString{String: "x", Valid: true}}
+	is.NoErr(dbFill(filled, row))
+	is.Equal("東京", filled.Address.City)
+	is.Equal([]string{"a", "b"}, filled.Tags)
+	is.Nil(filled.Options)
+	is.False(filled.Nick.Valid)
+
+	row.Columns["tags"] = "{"
+	is.Err(dbFill(filled, row))
+
+}
+
+// mysqlUnquote MySQLの文字列リテラルの解釈を再現します
+func mysqlUnquote(lit string) string {
+
+	lit = strings.TrimSuffix(strings.TrimPrefix(lit, "'"), "'")
+
+	var b strings.Builder
+
+	for i := 0; i < len(lit); i++ {
+
+		c := lit[i]
+
+		if c == '\'' && i+1 < len(lit) && lit[i+1] == '\'' {
+			b.WriteByte('\'')
+			i++
+			continue
+		}
+
+		if c != '\\' || i+1 == len(lit) {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+
+		switch lit[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '0':
+			b.WriteByte(0)
+		default:
+			b.WriteByte(lit[i])
+		}
+	}
+
+	return b.String()
+}
+
+func TestJSONColumnEscape(t *testing.T) {
+
+	is := is.New(t)
+
+	obj := &jsonObj{
+		ID:      1,
+		Address: jsonAddress{City: "O'Brien \"東京\"\n<b>&", Zip: `C:\tmp`},
+	}
+
+	val := reflect.ValueOf(obj).Elem()
+
+	cols, err := createColValMap(val, val.Type(), mapUpd)
+	is.NoErr(err)
+
+	stored := mysqlUnquote(cols["`address`"])
+	is.Equal(`{"city":"O'Brien \"東京\"\n\u003cb\u003e\u0026","zip":"C:\\tmp"}`, stored)
+
+	filled := &jsonObj{}
+	is.NoErr(dbFill(filled, &Row{Columns: map[string]string{"id": "1", "address": stored}}))
+	is.Equal(obj.Address, filled.Address)
+
+}
@@ -0,0 +1,112 @@
+package hyudb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// LockMode 行ロックの種類
+type LockMode int
+
+const (
+	// ForUpdate SELECT ... FOR UPDATE 排他ロック
+	ForUpdate LockMode = iota + 1
+	// ForShare SELECT ... FOR SHARE 共有ロック
+	ForShare
+)
+
+// LockWait ロック済みの行に対する動作
+type LockWait int
+
+const (
+	// LockWaitDefault ロックが解放されるまで待機します
+	LockWaitDefault LockWait = iota
+	// Nowait ロック済みの行がある場合は即座にエラーになります
+	Nowait
+	// SkipLocked ロック済みの行を読み飛ばします。ジョブキューなどに使用します
+	SkipLocked
+)
+
+// Lock 参照系クエリに付与する行ロックです
+type Lock struct {
+	Mode LockMode
+	Wait LockWait
+}
+
+// ErrLockOutsideTx はトランザクション外で行ロックを指定した場合のエラーです
+var ErrLockOutsideTx = errors.New("行ロックはトランザクション内でのみ使用できます")
+
+// WithLock は参照系クエリ（Get、SelectTop、QueryContext、Iterate、Repoなど）に行ロックを付与するcontextを返却します
+// トランザクション外で使用した場合はErrLockOutsideTxになります
+// Nowaitでロック済みの行があった場合などのエラーは呼び出し元に返却されます。IsLockConflictで判定できます
+// SelectQueryContext、SelectExistsContextはエラーを返却しないため、QueryContext、ExistsContextを使用してください
+func WithLock(ctx context.Context, lock Lock) context.Context {
+	return context.WithValue(ctx, ctxKeyLock, lock)
+}
+
+func lockFrom(ctx context.Context) (Lock, bool) {
+	l, ok := ctx.Value(ctxKeyLock).(Lock)
+	return l, ok && l.Mode != 0
+}
+
+// clause ロック句を返却します
+func (l Lock) clause() string {
+
+	ret := ""
+
+	switch l.Mode {
+	case ForUpdate:
+		ret = " FOR UPDATE"
+	case ForShare:
+		ret = " FOR SHARE"
+	default:
+		return ""
+	}
+
+	switch l.Wait {
+	case Nowait:
+		ret += " NOWAIT"
+	case SkipLocked:
+		ret += " SKIP LOCKED"
+	}
+
+	return ret
+}
+
+// lockQuery contextに行ロックが指定されている場合、queryにロック句を付与します
+func (db *DB) lockQuery(ctx context.Context, query string) (string, error) {
+
+	l, ok := lockFrom(ctx)
+
+	if !ok {
+		return query, nil
+	}
+
+	if db.transaction == nil {
+		return "", ErrLockOutsideTx
+	}
+
+	return query + l.clause(), nil
+}
+
+// mysqlErrLockNowait はNOWAITでロック済みの行があった場合のエラー番号です
+const mysqlErrLockNowait uint16 = 3572
+
+// IsLockConflict はNOWAITでのロックの取得失敗、ロック待ちタイムアウト、デッドロックのエラーかを返却します
+func IsLockConflict(err error) bool {
+
+	var me *mysql.MySQLError
+
+	if !errors.As(err, &me) {
+		return false
+	}
+
+	switch me.Number {
+	case mysqlErrLockNowait, mysqlErrLockWaitTimeout, mysqlErrDeadlock:
+		return true
+	}
+
+	return false
+}
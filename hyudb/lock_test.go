@@ -0,0 +1,87 @@
+package hyudb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/gara-snake/hyutil/internal/hyudbtest"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestLockQuery(t *testing.T) {
+
+	is := is.New(t)
+
+	db := &DB{}
+
+	q, err := db.lockQuery(context.Background(), "SELECT * FROM jobs")
+	is.NoErr(err)
+	is.Equal("SELECT * FROM jobs", q)
+
+	ctx := WithLock(context.Background(), Lock{Mode: ForUpdate, Wait: SkipLocked})
+
+	_, err = db.lockQuery(ctx, "SELECT * FROM jobs")
+	is.Equal(ErrLockOutsideTx, err)
+	is.Equal(ErrLockOutsideTx, db.GetContext(ctx, &auditObj{ID: 1}))
+
+	db.transaction = &sql.Tx{}
+
+	q, err = db.lockQuery(ctx, "SELECT * FROM jobs LIMIT 1")
+	is.NoErr(err)
+	is.Equal("SELECT * FROM jobs LIMIT 1 FOR UPDATE SKIP LOCKED", q)
+
+	q, err = db.lockQuery(WithLock(context.Background(), Lock{Mode: ForShare, Wait: Nowait}), "SELECT * FROM jobs")
+	is.NoErr(err)
+	is.Equal("SELECT * FROM jobs FOR SHARE NOWAIT", q)
+
+}
+
+func TestLockError(t *testing.T) {
+
+	is := is.New(t)
+
+	ctx := WithLock(context.Background(), Lock{Mode: ForUpdate, Wait: Nowait})
+
+	// トランザクション外ではエラーを返却し、終了しない
+	db := &DB{}
+
+	_, err := db.QueryContext(ctx, "SELECT * FROM jobs")
+	is.Equal(ErrLockOutsideTx, err)
+
+	_, err = db.ExistsContext(ctx, "SELECT * FROM jobs")
+	is.Equal(ErrLockOutsideTx, err)
+
+	is.Equal(ErrLockOutsideTx, db.SelectTopContext(ctx, "SELECT * FROM jobs", &auditObj{}))
+	is.Equal(0, len(db.SelectQueryContext(ctx, "SELECT * FROM jobs").Rows))
+	is.False(db.SelectExistsContext(ctx, "SELECT * FROM jobs"))
+
+	nowait := &mysql.MySQLError{Number: 3572}
+
+	fc := &hyudbtest.Connector{
+		Query: func(query string, args []driver.NamedValue) (*hyudbtest.Rows, error) {
+			return nil, nowait
+		},
+	}
+
+	db = newFakeDB(fc)
+
+	err = db.Transaction(func(tx *DB) error {
+
+		err := tx.GetContext(ctx, &auditObj{ID: 1})
+		is.Equal(nowait, err)
+		is.True(IsLockConflict(err))
+
+		// ロックできない場合に呼び出し元で処理を続けられる
+		return nil
+	})
+	is.NoErr(err)
+	is.True(strings.HasSuffix(fc.Queries()[0], "FROM audit_obj WHERE id = 1 FOR UPDATE NOWAIT"))
+	is.Equal("COMMIT", fc.Queries()[1])
+
+	is.False(IsLockConflict(&mysql.MySQLError{Number: 1062}))
+	is.False(IsLockConflict(nil))
+}
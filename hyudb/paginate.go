@@ -0,0 +1,186 @@
+package hyudb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+const pageAlias = "hyudb_page"
+
+// PageRequest ページングの要求です
+// KeyColumnを指定した場合はキーセット（シーク）方式、未指定の場合はLIMIT/OFFSET方式になります
+type PageRequest struct {
+	// Page 1始まりのページ番号（OFFSET方式のみ）
+	Page int
+	// Size 1ページの件数
+	Size int
+	// KeyColumn 並び順に使用する一意なカラム名（キーセット方式）
+	KeyColumn string
+	// After 前ページ最後の行のキー値。空の場合は先頭ページ（キーセット方式）
+	After string
+	// Desc キーの降順で取得します（キーセット方式）
+	Desc bool
+	// NoCount 総件数の取得を省略します
+	NoCount bool
+}
+
+// Page ページングの結果です
+type Page[T any] struct {
+	Items      []T
+	Page       int
+	Size       int
+	Total      int
+	TotalPages int
+	HasPrev    bool
+	HasNext    bool
+	// NextKey 次ページを取得する場合のAfterの値（キーセット方式）
+	NextKey string
+}
+
+// IsKeyset キーセット方式かどうかを返却します
+func (req *PageRequest) IsKeyset() bool {
+	return req.KeyColumn != ""
+}
+
+// Paginate queryの結果をページ単位で取得します。総件数はqueryを副問い合わせにしたCOUNTで取得します
+func Paginate[T any](db *DB, query string, req PageRequest, args ...interface{}) (*Page[T], error) {
+	return PaginateContext[T](context.Background(), db, query, req, args...)
+}
+
+// PaginateContext queryの結果をページ単位で取得します
+func PaginateContext[T any](ctx context.Context, db *DB, query string, req PageRequest, args ...interface{}) (*Page[T], error) {
+
+	if req.Size <= 0 {
+		return nil, errors.New("ページサイズが指定されていません")
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+
+	ret := &Page[T]{
+		Items: make([]T, 0, req.Size),
+		Page:  req.Page,
+		Size:  req.Size,
+	}
+
+	if !req.NoCount {
+
+		total, err := db.countQuery(ctx, createCountQuery(query), args...)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ret.Total = total
+		ret.TotalPages = (total + req.Size - 1) / req.Size
+	}
+
+	pageQuery, pageArgs := createPageQuery(query, &req, args)
+
+	err := db.IterateContext(ctx, pageQuery, func(r Row) error {
+
+		if len(ret.Items) == req.Size {
+			// Size+1件目は次ページの有無の判定にのみ使用する
+			ret.HasNext = true
+			return ErrStopIteration
+		}
+
+		var m T
+		if err := dbFill(&m, &r); err != nil {
+			return err
+		}
+		ret.Items = append(ret.Items, m)
+
+		if req.IsKeyset() {
+			ret.NextKey = r.Columns[req.KeyColumn]
+		}
+
+		return nil
+	}, pageArgs...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IsKeyset() {
+		ret.HasPrev = req.After != ""
+	} else {
+		ret.HasPrev = 1 < req.Page
+	}
+
+	if !ret.HasNext {
+		ret.NextKey = ""
+	}
+
+	return ret, nil
+}
+
+func createCountQuery(query string) string {
+	return " SELECT COUNT(*) AS counts FROM ( " + query + " ) AS " + pageAlias
+}
+
+// createPageQuery 1ページ分（次ページ判定用に+1件）を取得するクエリを作成します
+func createPageQuery(query string, req *PageRequest, args []interface{}) (string, []interface{}) {
+
+	limit := fmt.Sprint(req.Size + 1)
+
+	if !req.IsKeyset() {
+		offset := fmt.Sprint((req.Page - 1) * req.Size)
+		return query + " LIMIT " + limit + " OFFSET " + offset, args
+	}
+
+	key := ColEsc(req.KeyColumn)
+	op := " > "
+	order := " ASC "
+
+	if req.Desc {
+		op = " < "
+		order = " DESC "
+	}
+
+	ret := " SELECT * FROM ( " + query + " ) AS " + pageAlias
+
+	if req.After != "" {
+		ret += " WHERE " + key + op + "?"
+		args = append(append([]interface{}{}, args...), req.After)
+	}
+
+	ret += " ORDER BY " + key + order + " LIMIT " + limit
+
+	return ret, args
+}
+
+// countQuery 先頭行の先頭列を件数として返却します
+func (db *DB) countQuery(ctx context.Context, query string, args ...interface{}) (int, error) {
+
+	if db.Debug {
+		log.Println("SELECT QUERY : " + query)
+	}
+
+	rows, err := db.readQuery(ctx, query, args...)
+
+	if err != nil {
+		log.Println("Error query : " + query)
+		return 0, err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, errors.New("レコードが取得できませんでした。")
+	}
+
+	var ret int
+
+	if err := rows.Scan(&ret); err != nil {
+		return 0, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,25 @@
+package hyudb
+
+import (
+	"testing"
+
+	"github.com/cheekybits/is"
+)
+
+func TestCreatePageQuery(t *testing.T) {
+
+	is := is.New(t)
+
+	q, args := createPageQuery("SELECT * FROM test", &PageRequest{Page: 3, Size: 20}, nil)
+	is.Equal("SELECT * FROM test LIMIT 21 OFFSET 40", q)
+	is.Equal(0, len(args))
+
+	q, args = createPageQuery("SELECT * FROM test WHERE age > ?", &PageRequest{Size: 10, KeyColumn: "id"}, []interface{}{20})
+	is.Equal(" SELECT * FROM ( SELECT * FROM test WHERE age > ? ) AS hyudb_page ORDER BY `id` ASC  LIMIT 11", q)
+	is.Equal(1, len(args))
+
+	q, args = createPageQuery("SELECT * FROM test WHERE age > ?", &PageRequest{Size: 10, KeyColumn: "id", After: "100", Desc: true}, []interface{}{20})
+	is.Equal(" SELECT * FROM ( SELECT * FROM test WHERE age > ? ) AS hyudb_page WHERE `id` < ? ORDER BY `id` DESC  LIMIT 11", q)
+	is.Equal([]interface{}{20, "100"}, args)
+
+}
@@ -0,0 +1,373 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gara-snake/hyutil"
+	"github.com/gara-snake/hyutil/hyudb"
+	"github.com/gara-snake/hyutil/hyuio"
+)
+
+// DefaultTable はジョブを格納するテーブルの既定名です
+//
+//	CREATE TABLE hyu_job (
+//	  id           BIGINT AUTO_INCREMENT PRIMARY KEY,
+//	  queue        VARCHAR(64) NOT NULL,
+//	  payload      TEXT        NOT NULL,
+//	  status       VARCHAR(16) NOT NULL,
+//	  attempts     INT         NOT NULL DEFAULT 0,
+//	  max_attempts INT         NOT NULL,
+//	  run_at       DATETIME    NOT NULL,
+//	  locked_until DATETIME    NULL,
+//	  last_error   TEXT        NULL,
+//	  ins_date     DATETIME    NOT NULL,
+//	  upd_date     DATETIME    NOT NULL,
+//	  INDEX idx_hyu_job_claim (queue, status, run_at)
+//	)
+const DefaultTable = "hyu_job"
+
+const (
+	// StatusReady 実行待ち
+	StatusReady = "ready"
+	// StatusRunning 実行中
+	StatusRunning = "running"
+	// StatusDone 完了
+	StatusDone = "done"
+	// StatusDead 再試行の上限に達し、実行を諦めたジョブ（デッドレター）
+	StatusDead = "dead"
+)
+
+// Job キューに格納されたジョブ
+type Job struct {
+	ID          hyudb.DBID `hyudb:"pk"`
+	Queue       string
+	Payload     string
+	Status      string
+	Attempts    int32
+	MaxAttempts int32
+	RunAt       hyutil.DateTime
+	LockedUntil hyutil.DateTime
+	LastError   string
+	InsDate     hyutil.DateTime
+	UpdDate     hyutil.DateTime
+}
+
+// Decode PayloadのJSONをvに展開します
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(j.Payload), v)
+}
+
+// Handler ジョブの処理です。エラーを返却した場合は再試行されます
+type Handler func(ctx context.Context, job *Job) error
+
+// Queue データベースのテーブルを使用したジョブキューです
+type Queue struct {
+	// Name キュー名。同じテーブルに複数のキューを格納できます
+	Name string
+	// Table ジョブを格納するテーブル名
+	Table string
+	// MaxAttempts 既定の最大試行回数
+	MaxAttempts int
+	// Visibility 実行中のジョブを他のワーカーから隠す時間。これを過ぎても完了しない場合は再度取得されます
+	Visibility time.Duration
+	// PollInterval 実行可能なジョブがない場合の待機時間
+	PollInterval time.Duration
+	// Backoff attempt回目の失敗後、再実行までの待機時間
+	Backoff func(attempt int) time.Duration
+
+	db *hyudb.DB
+}
+
+// New キューを作成します
+func New(db *hyudb.DB, name string) *Queue {
+	return &Queue{
+		Name:         name,
+		Table:        DefaultTable,
+		MaxAttempts:  5,
+		Visibility:   5 * time.Minute,
+		PollInterval: time.Second,
+		Backoff:      DefaultBackoff,
+		db:           db,
+	}
+}
+
+// DefaultBackoff 10秒から倍々に、最大1時間まで待機時間を延ばします
+func DefaultBackoff(attempt int) time.Duration {
+
+	d := 10 * time.Second
+
+	for i := 1; i < attempt && d < time.Hour; i++ {
+		d *= 2
+	}
+
+	if time.Hour < d {
+		d = time.Hour
+	}
+
+	return d
+}
+
+// Enqueue ジョブを登録します。payloadはJSONに変換されます
+func (q *Queue) Enqueue(ctx context.Context, payload interface{}) (hyudb.DBID, error) {
+	return q.EnqueueDelay(ctx, payload, 0)
+}
+
+// EnqueueDelay delay経過後に実行されるジョブを登録します
+func (q *Queue) EnqueueDelay(ctx context.Context, payload interface{}, delay time.Duration) (hyudb.DBID, error) {
+
+	b, err := json.Marshal(payload)
+
+	if err != nil {
+		return hyudb.NoID, err
+	}
+
+	query :=
+		" INSERT INTO " + q.Table +
+			" (`queue`,`payload`,`status`,`attempts`,`max_attempts`,`run_at`,`ins_date`,`upd_date`) " +
+			" VALUES (?,?,?,0,?,NOW() + INTERVAL ? SECOND,NOW(),NOW()) "
+
+	_, id, err := q.db.ExecContext(ctx, query, q.Name, string(b), StatusReady, q.MaxAttempts, seconds(delay))
+
+	if err != nil {
+		return hyudb.NoID, err
+	}
+
+	return hyudb.DBID(id), nil
+}
+
+// Requeue デッドレターになったジョブを試行回数をリセットして実行待ちに戻します
+func (q *Queue) Requeue(ctx context.Context, id hyudb.DBID) error {
+
+	query :=
+		" UPDATE " + q.Table +
+			" SET `status` = ?, `attempts` = 0, `run_at` = NOW(), `locked_until` = NULL, `upd_date` = NOW() " +
+			" WHERE `id` = ? AND `queue` = ? AND `status` = ? "
+
+	n, _, err := q.db.ExecContext(ctx, query, StatusReady, id, q.Name, StatusDead)
+
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return hyudb.ErrNotFound
+	}
+
+	return nil
+}
+
+// errTimeout 可視性タイムアウトまでに完了しなかったジョブのエラーです
+const errTimeout = "可視性タイムアウトまでに完了しませんでした"
+
+// claim 実行可能なジョブを1件取得し、実行中にします。ジョブがない場合はnilです
+// 他のワーカーがロック中の行は SKIP LOCKED で読み飛ばします
+// 可視性タイムアウトを過ぎた実行中のジョブは再取得しますが、試行回数の上限に達している場合は
+// ロックしたその行のみデッドレターにし、次のジョブを取得します
+func (q *Queue) claim(ctx context.Context, db *hyudb.DB) (*Job, error) {
+
+	var job *Job
+
+	err := db.TransactionContext(ctx, func(tx *hyudb.DB) error {
+
+		query :=
+			" SELECT * FROM " + q.Table +
+				" WHERE `queue` = ? " +
+				" AND ( (`status` = ? AND `run_at` <= NOW()) OR (`status` = ? AND `locked_until` <= NOW()) ) " +
+				" ORDER BY `run_at`, `id` LIMIT 1 "
+
+		lctx := hyudb.WithLock(ctx, hyudb.Lock{Mode: hyudb.ForUpdate, Wait: hyudb.SkipLocked})
+
+		for {
+
+			job = nil
+
+			err := hyudb.EachContext(lctx, tx, query, func(m *Job) error {
+				j := *m
+				job = &j
+				return hyudb.ErrStopIteration
+			}, q.Name, StatusReady, StatusRunning)
+
+			if err != nil || job == nil {
+				return err
+			}
+
+			if job.Status != StatusRunning || job.Attempts < job.MaxAttempts {
+				break
+			}
+
+			dead :=
+				" UPDATE " + q.Table +
+					" SET `status` = ?, `locked_until` = NULL, `last_error` = ?, `upd_date` = NOW() WHERE `id` = ? "
+
+			if _, _, err := tx.ExecContext(ctx, dead, StatusDead, errTimeout, job.ID); err != nil {
+				return err
+			}
+		}
+
+		update :=
+			" UPDATE " + q.Table +
+				" SET `status` = ?, `attempts` = `attempts` + 1, `locked_until` = NOW() + INTERVAL ? SECOND, `upd_date` = NOW() " +
+				" WHERE `id` = ? "
+
+		_, _, err := tx.ExecContext(ctx, update, StatusRunning, seconds(q.Visibility), job.ID)
+
+		job.Status = StatusRunning
+		job.Attempts++
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// finish ジョブの実行結果を記録します
+// 可視性タイムアウトで他のワーカーが再取得している場合は試行回数が変わっているため更新しません
+func (q *Queue) finish(ctx context.Context, db *hyudb.DB, job *Job, jobErr error) error {
+
+	if jobErr == nil {
+
+		query :=
+			" UPDATE " + q.Table +
+				" SET `status` = ?, `locked_until` = NULL, `upd_date` = NOW() WHERE `id` = ? AND `attempts` = ? "
+
+		_, _, err := db.ExecContext(ctx, query, StatusDone, job.ID, job.Attempts)
+
+		return err
+	}
+
+	if job.MaxAttempts <= job.Attempts {
+
+		query :=
+			" UPDATE " + q.Table +
+				" SET `status` = ?, `locked_until` = NULL, `last_error` = ?, `upd_date` = NOW() WHERE `id` = ? AND `attempts` = ? "
+
+		_, _, err := db.ExecContext(ctx, query, StatusDead, jobErr.Error(), job.ID, job.Attempts)
+
+		return err
+	}
+
+	query :=
+		" UPDATE " + q.Table +
+			" SET `status` = ?, `locked_until` = NULL, `last_error` = ?, `run_at` = NOW() + INTERVAL ? SECOND, `upd_date` = NOW() " +
+			" WHERE `id` = ? AND `attempts` = ? "
+
+	_, _, err := db.ExecContext(ctx, query, StatusReady, jobErr.Error(), seconds(q.Backoff(int(job.Attempts))), job.ID, job.Attempts)
+
+	return err
+}
+
+// Work n個のワーカーでジョブを処理します。ctxがキャンセルされると新たなジョブの取得をやめ、
+// 処理中のジョブの完了を待ってから戻ります
+func (q *Queue) Work(ctx context.Context, n int, h Handler) {
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			q.worker(ctx, h)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (q *Queue) worker(ctx context.Context, h Handler) {
+
+	db := q.db.Session()
+
+	for ctx.Err() == nil {
+
+		job, err := q.claim(ctx, db)
+
+		if err != nil && ctx.Err() == nil {
+			log.Println("queue " + q.Name + " : " + err.Error())
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(q.PollInterval):
+			}
+			continue
+		}
+
+		// 終了要求があっても処理中のジョブは完了させる
+		jctx := context.WithoutCancel(ctx)
+
+		if err := q.finish(jctx, db, job, q.run(jctx, job, h)); err != nil {
+			log.Println("queue " + q.Name + " : " + err.Error())
+		}
+	}
+
+}
+
+func (q *Queue) run(ctx context.Context, job *Job, h Handler) (err error) {
+
+	ctx, cancel := context.WithTimeout(ctx, q.Visibility)
+	defer cancel()
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic : %v", p)
+		}
+	}()
+
+	return h(ctx, job)
+}
+
+// Pool Startで開始したワーカーです
+type Pool struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Start n個のワーカーをバックグラウンドで開始します
+// hyuio.WaitQuitでの終了時には、処理中のジョブの完了を待ってから終了します
+func (q *Queue) Start(n int, h Handler) *Pool {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := &Pool{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		q.Work(ctx, n, h)
+		close(p.done)
+	}()
+
+	hyuio.OnQuit(p.Stop)
+
+	return p
+}
+
+// Stop 新たなジョブの取得をやめ、処理中のジョブの完了を待ちます
+func (p *Pool) Stop() {
+	p.once.Do(p.cancel)
+	<-p.done
+}
+
+// seconds DATETIMEの精度に合わせて秒に切り上げます
+func seconds(d time.Duration) int64 {
+
+	if d <= 0 {
+		return 0
+	}
+
+	return int64((d + time.Second - 1) / time.Second)
+}
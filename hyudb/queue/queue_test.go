@@ -0,0 +1,118 @@
+package queue
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/gara-snake/hyutil/hyudb"
+	"github.com/gara-snake/hyutil/internal/hyudbtest"
+)
+
+func TestDefaultBackoff(t *testing.T) {
+
+	is := is.New(t)
+
+	is.Equal(10*time.Second, DefaultBackoff(1))
+	is.Equal(20*time.Second, DefaultBackoff(2))
+	is.Equal(80*time.Second, DefaultBackoff(4))
+	is.Equal(time.Hour, DefaultBackoff(20))
+
+}
+
+func TestSeconds(t *testing.T) {
+
+	is := is.New(t)
+
+	is.Equal(int64(0), seconds(0))
+	is.Equal(int64(1), seconds(time.Millisecond))
+	is.Equal(int64(300), seconds(5*time.Minute))
+
+}
+
+func TestJobDecode(t *testing.T) {
+
+	is := is.New(t)
+
+	job := &Job{Payload: `{"user_id":10,"mail":"taro@example.com"}`}
+
+	var p struct {
+		UserID int    `json:"user_id"`
+		Mail   string `json:"mail"`
+	}
+
+	is.NoErr(job.Decode(&p))
+	is.Equal(10, p.UserID)
+	is.Equal("taro@example.com", p.Mail)
+
+}
+
+var fake = &hyudbtest.Connector{}
+
+func init() {
+	hyudbtest.Register("queue_fake", fake)
+}
+
+// queries 実行されたクエリ。空白は1つにします
+func queries() []string {
+
+	ret := fake.Queries()
+
+	for i, q := range ret {
+		ret[i] = strings.Join(strings.Fields(q), " ")
+	}
+
+	return ret
+}
+
+func TestClaimDeadLetter(t *testing.T) {
+
+	is := is.New(t)
+
+	cols := []string{"id", "queue", "status", "attempts", "max_attempts"}
+	selects := 0
+
+	fake.Query = func(query string, args []driver.NamedValue) (*hyudbtest.Rows, error) {
+		selects++
+		switch selects {
+		case 1:
+			return &hyudbtest.Rows{Cols: cols, Vals: [][]driver.Value{{int64(1), "mail", StatusRunning, int64(3), int64(3)}}}, nil
+		case 2:
+			return &hyudbtest.Rows{Cols: cols, Vals: [][]driver.Value{{int64(2), "mail", StatusRunning, int64(1), int64(3)}}}, nil
+		}
+		return &hyudbtest.Rows{Cols: cols}, nil
+	}
+	defer func() { fake.Query = nil }()
+	fake.Reset()
+
+	db := hyudb.New("queue_fake", "")
+	defer db.Close()
+
+	q := New(db, "mail")
+
+	job, err := q.claim(context.Background(), db)
+	is.NoErr(err)
+	is.Equal(hyudb.DBID(2), job.ID)
+	is.Equal(int32(2), job.Attempts)
+
+	// 試行回数の上限に達したタイムアウトのジョブは、ロックした行のみデッドレターにして次のジョブを取得する
+	got := queries()
+	is.Equal(5, len(got))
+	is.True(strings.Contains(got[0], "(`status` = ? AND `locked_until` <= NOW())"))
+	is.True(strings.HasSuffix(got[0], "FOR UPDATE SKIP LOCKED"))
+	is.Equal("UPDATE hyu_job SET `status` = ?, `locked_until` = NULL, `last_error` = ?, `upd_date` = NOW() WHERE `id` = ?", got[1])
+	is.Equal(StatusDead, fake.Args()[1][0].Value)
+	is.Equal(int64(1), fake.Args()[1][2].Value)
+	is.True(strings.HasSuffix(got[2], "FOR UPDATE SKIP LOCKED"))
+	is.True(strings.HasPrefix(got[3], "UPDATE hyu_job SET `status` = ?, `attempts` = `attempts` + 1"))
+	is.Equal("COMMIT", got[4])
+
+	// ジョブがない場合
+	fake.Reset()
+	job, err = q.claim(context.Background(), db)
+	is.NoErr(err)
+	is.Nil(job)
+}
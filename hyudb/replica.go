@@ -0,0 +1,128 @@
+package hyudb
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+)
+
+// ReplicaEjectTime は異常を検知したレプリカを振り分け対象から外しておく時間です
+var ReplicaEjectTime = 30 * time.Second
+
+type replica struct {
+	connection *sql.DB
+	downUntil  time.Time
+}
+
+// replicaSet ラウンドロビンで振り分けるレプリカの集合
+type replicaSet struct {
+	mu    sync.Mutex
+	nodes []*replica
+	next  int
+}
+
+func openReplicaSet(dbType string, connectionstrs []string) *replicaSet {
+
+	if len(connectionstrs) == 0 {
+		return nil
+	}
+
+	rs := &replicaSet{}
+
+	for _, c := range connectionstrs {
+
+		db, err := sql.Open(dbType, c)
+
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		rs.nodes = append(rs.nodes, &replica{connection: db})
+	}
+
+	if len(rs.nodes) == 0 {
+		return nil
+	}
+
+	return rs
+}
+
+// candidates 振り分け可能なレプリカを今回の優先順で返却します
+func (rs *replicaSet) candidates(now time.Time) []*replica {
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	ret := make([]*replica, 0, len(rs.nodes))
+
+	for i := range rs.nodes {
+		r := rs.nodes[(rs.next+i)%len(rs.nodes)]
+		if now.Before(r.downUntil) {
+			continue
+		}
+		ret = append(ret, r)
+	}
+
+	rs.next = (rs.next + 1) % len(rs.nodes)
+
+	return ret
+}
+
+// eject レプリカを一定時間振り分け対象から外します
+func (rs *replicaSet) eject(r *replica, now time.Time) {
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	r.downUntil = now.Add(ReplicaEjectTime)
+}
+
+func (rs *replicaSet) close() {
+
+	for _, r := range rs.nodes {
+		r.connection.Close()
+	}
+
+}
+
+// readQuery 参照系クエリを実行します
+// トランザクション中、ForcePrimary指定時、レプリカ未設定時はプライマリーで実行されます
+// WithLockで行ロックが指定されている場合はロック句を付与します
+func (db *DB) readQuery(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+
+	query, err := db.lockQuery(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if db.transaction != nil {
+		return db.transaction.QueryContext(ctx, query, args...)
+	}
+
+	if db.replicas != nil && !isForcePrimary(ctx) {
+
+		for _, r := range db.replicas.candidates(time.Now()) {
+
+			rows, err := r.connection.QueryContext(ctx, query, args...)
+
+			if err == nil {
+				return rows, nil
+			}
+
+			// 接続できている場合はクエリ自体の問題なのでそのまま返却する
+			if ctx.Err() != nil || r.connection.PingContext(ctx) == nil {
+				return nil, err
+			}
+
+			log.Println("replica ejected : " + err.Error())
+			db.replicas.eject(r, time.Now())
+		}
+
+	}
+
+	return db.connection.QueryContext(ctx, query, args...)
+}
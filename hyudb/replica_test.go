@@ -0,0 +1,45 @@
+package hyudb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+)
+
+func TestReplicaRoundRobin(t *testing.T) {
+
+	is := is.New(t)
+
+	r1, r2, r3 := &replica{}, &replica{}, &replica{}
+	rs := &replicaSet{nodes: []*replica{r1, r2, r3}}
+
+	now := time.Now()
+
+	is.Equal(r1, rs.candidates(now)[0])
+	is.Equal(r2, rs.candidates(now)[0])
+	is.Equal(r3, rs.candidates(now)[0])
+	is.Equal(r1, rs.candidates(now)[0])
+
+	rs.eject(r2, now)
+
+	c := rs.candidates(now)
+	is.Equal(2, len(c))
+	is.Equal(r3, c[0])
+	is.Equal(r1, c[1])
+
+	// 時間経過で復帰する
+	c = rs.candidates(now.Add(ReplicaEjectTime))
+	is.Equal(3, len(c))
+
+}
+
+func TestForcePrimary(t *testing.T) {
+
+	is := is.New(t)
+
+	is.False(isForcePrimary(context.Background()))
+	is.True(isForcePrimary(ForcePrimary(context.Background())))
+
+}
@@ -0,0 +1,160 @@
+package hyudb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// Repo はモデルの型を固定したデータアクセスです
+// Tは *User のように構造体へのポインター型を指定します
+type Repo[T Modeler] struct {
+	db *DB
+	tp reflect.Type
+	pk reflect.StructField
+}
+
+// NewRepo Repoを作成します。Tが構造体へのポインターでない場合、プライマリーキーの指定がない場合はエラーを返却します
+func NewRepo[T Modeler](db *DB) (*Repo[T], error) {
+
+	tp := reflect.TypeOf((*T)(nil)).Elem()
+
+	if tp.Kind() != reflect.Ptr || tp.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("hyudb.NewRepo: 型パラメーターが構造体へのポインターではありません : " + tp.String())
+	}
+
+	pk, ok := modelPK(tp.Elem())
+
+	if !ok {
+		return nil, errors.New("hyudb.NewRepo: プライマリーキーの指定がありません : " + tp.String())
+	}
+
+	return &Repo[T]{
+		db: db,
+		tp: tp.Elem(),
+		pk: pk,
+	}, nil
+}
+
+func (r *Repo[T]) newModel() T {
+	return reflect.New(r.tp).Interface().(T)
+}
+
+func (r *Repo[T]) pkValue(m T) reflect.Value {
+	return reflect.ValueOf(m).Elem().FieldByIndex(r.pk.Index)
+}
+
+// Find プライマリーキーで1件取得します。該当がない場合はErrNotFoundを返却します
+func (r *Repo[T]) Find(ctx context.Context, id DBID) (T, error) {
+
+	m := r.newModel()
+
+	switch pk := r.pkValue(m); pk.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		pk.SetInt(int64(id))
+	default:
+		var zero T
+		return zero, errors.New("プライマーキーの型が不明です。")
+	}
+
+	if err := r.db.GetContext(ctx, m); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return m, nil
+}
+
+// FindBy 条件に一致する行を取得します。condはWHERE句の内容で、argsはプレースホルダーの値です
+func (r *Repo[T]) FindBy(ctx context.Context, cond string, args ...interface{}) ([]T, error) {
+
+	query, err := createSelectAllQuery(r.newModel())
+
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := tenantCond(ctx, r.tp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tenant != "" {
+		return r.list(ctx, query+" WHERE "+tenant+" AND ( "+cond+" )", args...)
+	}
+
+	return r.list(ctx, query+" WHERE "+cond, args...)
+}
+
+// List 全件を取得します
+func (r *Repo[T]) List(ctx context.Context) ([]T, error) {
+
+	query, err := createSelectAllQuery(r.newModel())
+
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := tenantCond(ctx, r.tp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tenant != "" {
+		query += " WHERE " + tenant
+	}
+
+	return r.list(ctx, query)
+}
+
+func (r *Repo[T]) list(ctx context.Context, query string, args ...interface{}) ([]T, error) {
+
+	ret := make([]T, 0)
+
+	err := r.db.IterateContext(ctx, query, func(row Row) error {
+		m := r.newModel()
+		if err := dbFill(m, &row); err != nil {
+			return err
+		}
+		ret = append(ret, m)
+		return nil
+	}, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// Insert 新規に登録します。採番されたIDはmに設定されます
+func (r *Repo[T]) Insert(ctx context.Context, m T) error {
+
+	if !r.pkValue(m).IsZero() {
+		return errors.New("新規登録でプライマリーキーが指定されています")
+	}
+
+	return r.db.SaveContext(ctx, m)
+}
+
+// Update 登録済みの行を更新します
+func (r *Repo[T]) Update(ctx context.Context, m T) error {
+
+	if r.pkValue(m).IsZero() {
+		return errors.New("更新でプライマリーキーが指定されていません")
+	}
+
+	return r.db.SaveContext(ctx, m)
+}
+
+// Delete 登録済みの行を物理削除します
+func (r *Repo[T]) Delete(ctx context.Context, m T) error {
+
+	if r.pkValue(m).IsZero() {
+		return errors.New("削除でプライマリーキーが指定されていません")
+	}
+
+	return r.db.DelForeverContext(ctx, m)
+}
@@ -0,0 +1,164 @@
+package hyudb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	mysqlErrLockWaitTimeout uint16 = 1205
+	mysqlErrDeadlock        uint16 = 1213
+)
+
+// RetryPolicy はTransactionでの再試行方針です
+type RetryPolicy struct {
+	// MaxAttempts 初回を含めた最大試行回数。1以下の場合は再試行しません
+	MaxAttempts int
+	// BaseDelay 初回再試行までの待機時間。以降は試行ごとに倍になります
+	BaseDelay time.Duration
+	// MaxDelay 待機時間の上限
+	MaxDelay time.Duration
+	// OnRetry 再試行の直前に呼び出されます。attemptは失敗した試行の回数です
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// DefaultRetryPolicy は既定の再試行方針です
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   50 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+}
+
+// delay attempt回目の失敗後の待機時間を返却します（指数バックオフ、後半半分をジッター）
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+
+	d := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+
+	if 0 < p.MaxDelay && p.MaxDelay < d {
+		d = p.MaxDelay
+	}
+
+	if d <= 0 {
+		return 0
+	}
+
+	half := d / 2
+
+	return half + rand.N(d-half+1)
+}
+
+// IsRetryable はデッドロック、ロック待ちタイムアウト、接続断など再試行で解消しうるエラーかを返却します
+func IsRetryable(err error) bool {
+
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		switch me.Number {
+		case mysqlErrDeadlock, mysqlErrLockWaitTimeout:
+			return true
+		}
+	}
+
+	return false
+}
+
+// Transaction fnをトランザクション内で実行します
+func (db *DB) Transaction(fn func(tx *DB) error) error {
+	return db.TransactionContext(context.Background(), fn)
+}
+
+// TransactionContext fnをトランザクション内で実行します
+// fnがエラーを返却した場合、または実行中のクエリでエラーがあった場合はロールバックし、
+// 再試行可能なエラーであればRetryの方針に従ってfnを最初から実行し直します
+// すでにトランザクション中の場合は再試行せず、そのトランザクション内でfnを実行します
+func (db *DB) TransactionContext(ctx context.Context, fn func(tx *DB) error) error {
+
+	if db.transaction != nil {
+		return fn(db)
+	}
+
+	policy := db.Retry
+	if policy == nil {
+		policy = &DefaultRetryPolicy
+	}
+
+	for attempt := 1; ; attempt++ {
+
+		err := db.runTx(ctx, fn)
+
+		if err == nil {
+			return nil
+		}
+
+		if policy.MaxAttempts <= attempt || !IsRetryable(err) {
+			return err
+		}
+
+		d := policy.delay(attempt)
+
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, err, d)
+		}
+
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+}
+
+func (db *DB) runTx(ctx context.Context, fn func(tx *DB) error) (err error) {
+
+	tx, err := db.connection.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	db.transaction = tx
+	db.hasErr = false
+	db.err = nil
+
+	defer func() {
+
+		if p := recover(); p != nil {
+			tx.Rollback()
+			db.transaction = nil
+			panic(p)
+		}
+
+		if err == nil {
+			err = db.err
+		}
+
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+
+		db.transaction = nil
+		db.hasErr = false
+		db.err = nil
+
+	}()
+
+	return fn(db)
+}
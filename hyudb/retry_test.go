@@ -0,0 +1,169 @@
+package hyudb
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/is"
+	"github.com/gara-snake/hyutil/internal/hyudbtest"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestIsRetryable(t *testing.T) {
+
+	is := is.New(t)
+
+	is.True(IsRetryable(&mysql.MySQLError{Number: 1213}))
+	is.True(IsRetryable(&mysql.MySQLError{Number: 1205}))
+	is.True(IsRetryable(fmt.Errorf("exec : %w", driver.ErrBadConn)))
+	is.False(IsRetryable(&mysql.MySQLError{Number: 1062}))
+	is.False(IsRetryable(errors.New("error")))
+	is.False(IsRetryable(nil))
+
+}
+
+func TestRetryDelay(t *testing.T) {
+
+	is := is.New(t)
+
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for i := 0; i < 100; i++ {
+
+		d := p.delay(1)
+		is.True(50*time.Millisecond <= d && d <= 100*time.Millisecond)
+
+		d = p.delay(3)
+		is.True(200*time.Millisecond <= d && d <= 400*time.Millisecond)
+
+		d = p.delay(10)
+		is.True(500*time.Millisecond <= d && d <= time.Second)
+
+	}
+
+}
+
+func TestReadErr(t *testing.T) {
+
+	is := is.New(t)
+
+	deadlock := &mysql.MySQLError{Number: 1213}
+
+	// トランザクション外では記録しない
+	db := newFakeDB(&hyudbtest.Connector{})
+	is.Equal(deadlock, db.readErr("SELECT 1", deadlock))
+	is.False(db.hasErr)
+
+	tx, err := db.connection.Begin()
+	is.NoErr(err)
+	defer tx.Rollback()
+	db.transaction = tx
+
+	err = db.readErr("SELECT 1", &mysql.MySQLError{Number: 1062})
+	is.Equal(1062, int(err.(*mysql.MySQLError).Number))
+	is.False(db.hasErr)
+
+	is.Equal(deadlock, db.readErr("SELECT 1", deadlock))
+	is.True(db.hasErr)
+	is.Equal(deadlock, db.err)
+
+}
+
+func TestTransactionRetryRead(t *testing.T) {
+
+	is := is.New(t)
+
+	reads := 0
+
+	fc := &hyudbtest.Connector{
+		Query: func(query string, args []driver.NamedValue) (*hyudbtest.Rows, error) {
+			reads++
+			if reads == 1 {
+				return nil, &mysql.MySQLError{Number: 1213}
+			}
+			return &hyudbtest.Rows{Cols: []string{"id", "name"}, Vals: [][]driver.Value{{int64(1), "テスト"}}}, nil
+		},
+	}
+
+	db := newFakeDB(fc)
+	db.Retry = &RetryPolicy{MaxAttempts: 3}
+
+	calls := 0
+
+	// 読み込み中のデッドロックもロールバックして再試行する
+	err := db.Transaction(func(tx *DB) error {
+		calls++
+		tx.SelectQuery("SELECT * FROM audit_obj")
+		return nil
+	})
+	is.NoErr(err)
+	is.Equal(2, calls)
+	is.Equal([]string{"SELECT * FROM audit_obj", "ROLLBACK", "SELECT * FROM audit_obj", "COMMIT"}, fc.Queries())
+
+	// 再試行できないエラーは返却する
+	fc.Query = func(query string, args []driver.NamedValue) (*hyudbtest.Rows, error) {
+		return nil, &mysql.MySQLError{Number: 1146}
+	}
+
+	_, err = db.QueryContext(context.Background(), "SELECT * FROM none")
+	is.Equal(uint16(1146), err.(*mysql.MySQLError).Number)
+
+	fc.Exec = func(query string, args []driver.NamedValue) (driver.Result, error) {
+		return nil, &mysql.MySQLError{Number: 1146}
+	}
+
+	// Execは終了せずに-1を返却する
+	affected, _ := db.Exec("UPDATE none SET a = 1")
+	is.Equal(int64(-1), affected)
+}
+
+func TestTransactionRetryIterate(t *testing.T) {
+
+	is := is.New(t)
+
+	reads := 0
+
+	fc := &hyudbtest.Connector{
+		Query: func(query string, args []driver.NamedValue) (*hyudbtest.Rows, error) {
+			reads++
+			if reads == 1 {
+				return nil, &mysql.MySQLError{Number: 1213}
+			}
+			return &hyudbtest.Rows{Cols: []string{"id"}, Vals: [][]driver.Value{{int64(1)}, {int64(2)}}}, nil
+		},
+	}
+
+	db := newFakeDB(fc)
+	db.Retry = &RetryPolicy{MaxAttempts: 3}
+
+	ids := make([]string, 0)
+
+	// Iterateのデッドロックもロールバックして再試行する
+	err := db.Transaction(func(tx *DB) error {
+		ids = ids[:0]
+		return tx.Iterate("SELECT id FROM audit_obj", func(r Row) error {
+			ids = append(ids, r.Columns["id"])
+			return nil
+		})
+	})
+	is.NoErr(err)
+	is.Equal([]string{"1", "2"}, ids)
+	is.Equal([]string{"SELECT id FROM audit_obj", "ROLLBACK", "SELECT id FROM audit_obj", "COMMIT"}, fc.Queries())
+
+	// fnのエラーはクエリのエラーとして記録せずにそのまま返却する
+	fc.Reset()
+	fnErr := &mysql.MySQLError{Number: 1213}
+
+	err = db.Transaction(func(tx *DB) error {
+		err := tx.Iterate("SELECT id FROM audit_obj", func(r Row) error {
+			return fnErr
+		})
+		is.False(tx.hasErr)
+		return err
+	})
+	is.Equal(fnErr, err)
+}
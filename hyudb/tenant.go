@@ -0,0 +1,120 @@
+package hyudb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// tagTenant はテナントIDのフィールドのタグ値です
+//
+//	TenantID DBID `hyudb:"tenant" hyudb_col:"tenant_id"`
+const tagTenant = "tenant"
+
+// ErrNoTenant はテナントで分離されたモデルをテナントの指定なしで操作した場合のエラーです
+var ErrNoTenant = errors.New("テナントが指定されていません")
+
+// modelTenant はモデルのテナントIDのフィールドを返却します
+func modelTenant(tp reflect.Type) (reflect.StructField, bool) {
+
+	for i := 0; i < tp.NumField(); i++ {
+
+		field := tp.Field(i)
+
+		if field.Tag.Get("hyudb") == tagTenant {
+			return field, true
+		}
+
+	}
+
+	return reflect.StructField{}, false
+}
+
+// tenantCond テナントの条件式を返却します。テナントで分離されていないモデルの場合は空文字列です
+func tenantCond(ctx context.Context, tp reflect.Type) (string, error) {
+
+	field, ok := modelTenant(tp)
+
+	if !ok {
+		return "", nil
+	}
+
+	tenant, ok := TenantFrom(ctx)
+
+	if !ok {
+		return "", ErrNoTenant
+	}
+
+	return ColEsc(colName(field)) + " = " + fmt.Sprint(tenant), nil
+}
+
+// scopeQuery WHERE句で終わるqueryにテナントの条件を追加します
+func scopeQuery(ctx context.Context, query string, tp reflect.Type) (string, error) {
+
+	cond, err := tenantCond(ctx, tp)
+
+	if err != nil || cond == "" {
+		return query, err
+	}
+
+	return query + " AND " + cond, nil
+}
+
+// checkTenantRow 更新した行数が0の場合に、テナントの行が存在するか確認します
+// MySQLは値が変わらない行を更新した行数に含めないため、件数だけでは判断できません
+func (db *DB) checkTenantRow(ctx context.Context, model interface{}, tp reflect.Type) error {
+
+	if _, ok := modelTenant(tp); !ok {
+		return nil
+	}
+
+	query, err := createSelectQuery(model)
+
+	if err != nil {
+		return err
+	}
+
+	if query, err = scopeQuery(ctx, query, tp); err != nil {
+		return err
+	}
+
+	ok, err := db.ExistsContext(ctx, query)
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// applyTenant モデルのテナントIDにcontextのテナントを設定します
+func applyTenant(ctx context.Context, val reflect.Value, tp reflect.Type) error {
+
+	field, ok := modelTenant(tp)
+
+	if !ok {
+		return nil
+	}
+
+	tenant, ok := TenantFrom(ctx)
+
+	if !ok {
+		return ErrNoTenant
+	}
+
+	dest := val.FieldByIndex(field.Index)
+
+	switch dest.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		dest.SetInt(int64(tenant))
+	default:
+		return errors.New("テナントIDの型が不明です : " + dest.Type().String())
+	}
+
+	return nil
+}
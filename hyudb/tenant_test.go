@@ -0,0 +1,82 @@
+package hyudb
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/gara-snake/hyutil/internal/hyudbtest"
+)
+
+type tenantObj struct {
+	ID       DBID `hyudb:"pk"`
+	TenantID DBID `hyudb:"tenant" hyudb_col:"tenant_id"`
+	Name     string
+}
+
+func TestTenantScope(t *testing.T) {
+
+	is := is.New(t)
+
+	obj := &tenantObj{ID: 3, TenantID: 99}
+	val := reflect.ValueOf(obj).Elem()
+	tp := val.Type()
+
+	query, err := createSelectQuery(obj)
+	is.NoErr(err)
+
+	// テナント未指定は実行させない
+	_, err = scopeQuery(context.Background(), query, tp)
+	is.Equal(ErrNoTenant, err)
+	is.Equal(ErrNoTenant, applyTenant(context.Background(), val, tp))
+
+	ctx := WithTenant(context.Background(), 5)
+
+	scoped, err := scopeQuery(ctx, query, tp)
+	is.NoErr(err)
+	is.Equal(query+" AND `tenant_id` = 5", scoped)
+
+	is.NoErr(applyTenant(ctx, val, tp))
+	is.Equal(DBID(5), obj.TenantID)
+
+	// テナントで分離されていないモデルはそのまま
+	scoped, err = scopeQuery(context.Background(), query, reflect.TypeOf(auditObj{}))
+	is.NoErr(err)
+	is.Equal(query, scoped)
+
+}
+
+func TestTenantNoRows(t *testing.T) {
+
+	is := is.New(t)
+
+	exists := false
+
+	fc := &hyudbtest.Connector{
+		Exec: func(query string, args []driver.NamedValue) (driver.Result, error) {
+			return hyudbtest.Result{}, nil
+		},
+		Query: func(query string, args []driver.NamedValue) (*hyudbtest.Rows, error) {
+			if !exists {
+				return &hyudbtest.Rows{Cols: []string{"id"}}, nil
+			}
+			return &hyudbtest.Rows{Cols: []string{"id"}, Vals: [][]driver.Value{{int64(3)}}}, nil
+		},
+	}
+
+	db := newFakeDB(fc)
+	ctx := WithTenant(context.Background(), 5)
+
+	// 他のテナントの行は0件更新になる
+	is.Equal(ErrNotFound, db.SaveContext(ctx, &tenantObj{ID: 3, Name: "x"}))
+	is.Equal(ErrNotFound, db.DelForeverContext(ctx, &tenantObj{ID: 3}))
+	is.True(strings.HasSuffix(fc.Queries()[1], "`tenant_id` = 5"))
+
+	// 値が変わらない場合も0件更新になるため、行があればエラーにしない
+	exists = true
+	is.NoErr(db.SaveContext(ctx, &tenantObj{ID: 3, Name: "x"}))
+
+}
@@ -0,0 +1,470 @@
+package hyufixed
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gara-snake/hyutil"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// FieldError レコードまたは項目の誤り
+type FieldError struct {
+	// Record 1始まりのレコード番号
+	Record int
+	// Code レコード種別
+	Code string
+	// Field 項目のフィールド名。レコード全体の誤りの場合は空です
+	Field  string
+	Offset int
+	Width  int
+	Value  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+
+	if e.Field == "" {
+		return fmt.Sprintf("%dレコード目 : %s", e.Record, e.Reason)
+	}
+
+	return fmt.Sprintf("%dレコード目 %s(%d-%d) '%s' : %s", e.Record, e.Field, e.Offset+1, e.Offset+e.Width, e.Value, e.Reason)
+}
+
+// ValidationError レコードごとの誤りの一覧
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+
+	list := make([]string, 0, len(e.Errors))
+
+	for _, fe := range e.Errors {
+		list = append(list, fe.Error())
+	}
+
+	return "固定長レコードが不正です\n" + strings.Join(list, "\n")
+}
+
+func (e *ValidationError) add(errs []*FieldError) *ValidationError {
+
+	if len(errs) == 0 {
+		return e
+	}
+
+	if e == nil {
+		e = &ValidationError{}
+	}
+
+	e.Errors = append(e.Errors, errs...)
+
+	return e
+}
+
+// ErrUnregistered 登録されていない型です
+var ErrUnregistered = errors.New("hyufixed:登録されていないレコードの型です")
+
+// Marshal レコードを登録された型のレイアウトで変換します。recordsの要素は構造体または構造体へのポインターです
+// 項目に収まらない値などがある場合は、全てのレコードを確認した後に*ValidationErrorを返却します
+func (l *Layout) Marshal(records []interface{}) ([]byte, error) {
+
+	var buf bytes.Buffer
+
+	if err := l.Write(&buf, records); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Write レコードを変換してwに書き込みます。誤りのあるレコードがある場合は何も書き込みません
+func (l *Layout) Write(w io.Writer, records []interface{}) error {
+
+	var verr *ValidationError
+
+	out := make([][]byte, 0, len(records))
+
+	for i, r := range records {
+
+		rec, errs, err := l.encode(i+1, r)
+
+		if err != nil {
+			return err
+		}
+
+		verr = verr.add(errs)
+		out = append(out, rec)
+	}
+
+	if verr != nil {
+		return verr
+	}
+
+	for _, rec := range out {
+
+		if _, err := w.Write(rec); err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(w, l.Separator); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Encode 1レコードを変換します
+func (l *Layout) Encode(record interface{}) ([]byte, error) {
+
+	rec, errs, err := l.encode(1, record)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if 0 < len(errs) {
+		return nil, &ValidationError{Errors: errs}
+	}
+
+	return rec, nil
+}
+
+func (l *Layout) encode(no int, record interface{}) ([]byte, []*FieldError, error) {
+
+	if err := l.checkLen(); err != nil {
+		return nil, nil, err
+	}
+
+	val := reflect.ValueOf(record)
+
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		val = val.Elem()
+	}
+
+	if !val.IsValid() {
+		return nil, nil, ErrUnregistered
+	}
+
+	rt, ok := l.typeOf(val.Type())
+
+	if !ok {
+		return nil, nil, fmt.Errorf("%w：%s", ErrUnregistered, val.Type())
+	}
+
+	rec := bytes.Repeat([]byte{' '}, l.RecordLen)
+	copy(rec, rt.code)
+
+	var errs []*FieldError
+
+	for _, f := range rt.fields {
+
+		b, reason := f.encode(val.FieldByIndex(f.index))
+
+		if reason != "" {
+			errs = append(errs, &FieldError{
+				Record: no,
+				Code:   rt.code,
+				Field:  f.name,
+				Offset: f.offset,
+				Width:  f.width,
+				Value:  fmt.Sprint(val.FieldByIndex(f.index).Interface()),
+				Reason: reason,
+			})
+			continue
+		}
+
+		copy(rec[f.offset:], b)
+	}
+
+	return rec, errs, nil
+}
+
+// encode 項目の値をShift_JISで幅に合わせて埋めます。誤りの場合は理由を返却します
+func (f *field) encode(v reflect.Value) ([]byte, string) {
+
+	var s string
+
+	switch v.Kind() {
+	case reflect.String:
+		s = v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() < 0 && f.pad == '0' {
+			return nil, "負の数は0埋めできません"
+		}
+		s = strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = strconv.FormatUint(v.Uint(), 10)
+	default:
+		dt := v.Interface().(hyutil.DateTime)
+		if dt != hyutil.DateTimeZero {
+			s = dt.Format(f.date)
+		}
+	}
+
+	b, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte(s))
+
+	if err != nil {
+		return nil, "Shift_JISに変換できない文字があります"
+	}
+
+	if f.width < len(b) {
+		return nil, fmt.Sprintf("%dバイトを超えています（%dバイト）", f.width, len(b))
+	}
+
+	pad := bytes.Repeat([]byte{f.pad}, f.width-len(b))
+
+	if f.align == AlignRight {
+		return append(pad, b...), ""
+	}
+
+	return append(b, pad...), ""
+}
+
+// Unmarshal 固定長ファイルを読み込み、レコードごとに登録された型の構造体へのポインターを返却します
+// 誤りのあるレコードがある場合は、全てのレコードを読み込んだ後に*ValidationErrorを返却します
+func (l *Layout) Unmarshal(data []byte) ([]interface{}, error) {
+
+	if err := l.checkLen(); err != nil {
+		return nil, err
+	}
+
+	records := l.split(data)
+	ret := make([]interface{}, 0, len(records))
+
+	var verr *ValidationError
+
+	for i, rec := range records {
+
+		v, errs := l.decode(i+1, rec)
+
+		verr = verr.add(errs)
+
+		if v != nil {
+			ret = append(ret, v)
+		}
+	}
+
+	if verr != nil {
+		return ret, verr
+	}
+
+	return ret, nil
+}
+
+// Read rから固定長ファイルを読み込みます
+func (l *Layout) Read(r io.Reader) ([]interface{}, error) {
+
+	b, err := io.ReadAll(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Unmarshal(b)
+}
+
+// Decode 1レコードをvに展開します。vは登録された型の構造体へのポインターです
+func (l *Layout) Decode(rec []byte, v interface{}) error {
+
+	val := reflect.ValueOf(v)
+
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return errors.New("hyufixed:構造体へのポインターではありません")
+	}
+
+	rt, ok := l.typeOf(val.Elem().Type())
+
+	if !ok {
+		return fmt.Errorf("%w：%s", ErrUnregistered, val.Elem().Type())
+	}
+
+	if errs := l.check(1, rec, rt); 0 < len(errs) {
+		return &ValidationError{Errors: errs}
+	}
+
+	if !bytes.HasPrefix(rec, []byte(rt.code)) {
+		return &ValidationError{Errors: []*FieldError{{Record: 1, Code: rt.code, Reason: "レコード種別が" + rt.code + "ではありません"}}}
+	}
+
+	if errs := rt.decode(1, rec, val.Elem()); 0 < len(errs) {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+// split レコードに分割します。末尾の区切りとEOF(0x1A)は無視します
+func (l *Layout) split(data []byte) [][]byte {
+
+	data = bytes.TrimRight(data, "\x1a")
+
+	if l.Separator != "" {
+
+		data = bytes.TrimSuffix(data, []byte(l.Separator))
+
+		if len(data) == 0 {
+			return nil
+		}
+
+		return bytes.Split(data, []byte(l.Separator))
+	}
+
+	ret := make([][]byte, 0, len(data)/l.RecordLen+1)
+
+	for 0 < len(data) {
+
+		n := l.RecordLen
+		if len(data) < n {
+			n = len(data)
+		}
+
+		ret = append(ret, data[:n])
+		data = data[n:]
+	}
+
+	return ret
+}
+
+func (l *Layout) check(no int, rec []byte, rt *recordType) []*FieldError {
+
+	if len(rec) != l.RecordLen {
+
+		code := ""
+		if rt != nil {
+			code = rt.code
+		}
+
+		return []*FieldError{{
+			Record: no,
+			Code:   code,
+			Reason: fmt.Sprintf("レコード長が%dバイトではありません（%dバイト）", l.RecordLen, len(rec)),
+		}}
+	}
+
+	return nil
+}
+
+func (l *Layout) decode(no int, rec []byte) (interface{}, []*FieldError) {
+
+	rt, ok := l.typeOfRecord(rec)
+
+	if !ok {
+
+		code := ""
+		if 0 < len(rec) {
+			code = string(rec[:1])
+		}
+
+		return nil, []*FieldError{{
+			Record: no,
+			Code:   code,
+			Reason: "不明なレコード種別です：" + code,
+		}}
+	}
+
+	if errs := l.check(no, rec, rt); 0 < len(errs) {
+		return nil, errs
+	}
+
+	v := reflect.New(rt.tp)
+
+	return v.Interface(), rt.decode(no, rec, v.Elem())
+}
+
+func (rt *recordType) decode(no int, rec []byte, val reflect.Value) []*FieldError {
+
+	var errs []*FieldError
+
+	for _, f := range rt.fields {
+
+		raw := rec[f.offset : f.offset+f.width]
+
+		if reason := f.decode(raw, val.FieldByIndex(f.index)); reason != "" {
+
+			s, _ := japanese.ShiftJIS.NewDecoder().Bytes(raw)
+
+			errs = append(errs, &FieldError{
+				Record: no,
+				Code:   rt.code,
+				Field:  f.name,
+				Offset: f.offset,
+				Width:  f.width,
+				Value:  string(s),
+				Reason: reason,
+			})
+		}
+	}
+
+	return errs
+}
+
+// decode 埋めた文字を除いて項目の値を設定します。誤りの場合は理由を返却します
+func (f *field) decode(raw []byte, v reflect.Value) string {
+
+	b, err := japanese.ShiftJIS.NewDecoder().Bytes(raw)
+
+	if err != nil {
+		return "Shift_JISではありません"
+	}
+
+	s := string(b)
+
+	if f.align == AlignRight {
+		// 0埋めなどの文字列は先頭の文字も値のため、数値のみ埋めた文字を除く
+		if v.Kind() == reflect.String {
+			s = strings.TrimLeft(s, " ")
+		} else {
+			s = strings.TrimLeft(s, string(f.pad))
+		}
+	} else {
+		s = strings.TrimRight(s, string(f.pad))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strings.TrimSpace(s)
+		if s == "" {
+			v.SetInt(0)
+			return ""
+		}
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return "数値ではありません"
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = strings.TrimSpace(s)
+		if s == "" {
+			v.SetUint(0)
+			return ""
+		}
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return "数値ではありません"
+		}
+		v.SetUint(i)
+	default:
+		s = strings.TrimSpace(string(b))
+		if s == "" || strings.Trim(s, "0") == "" {
+			v.Set(reflect.ValueOf(hyutil.DateTimeZero))
+			return ""
+		}
+		t, err := time.ParseInLocation(f.date, s, time.Local)
+		if err != nil {
+			return "日付の書式(" + f.date + ")と一致しません"
+		}
+		v.Set(reflect.ValueOf(hyutil.DateTime{Time: &t}))
+	}
+
+	return ""
+}
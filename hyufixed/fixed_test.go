@@ -0,0 +1,173 @@
+package hyufixed
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/gara-snake/hyutil"
+	"golang.org/x/text/encoding/japanese"
+)
+
+type header struct {
+	Kind     string          `fixed:"width=2,zero"`
+	Date     hyutil.DateTime `fixed:"width=4,date=0102"`
+	BankCode string          `fixed:"width=4"`
+	BankName string          `fixed:"width=10"`
+}
+
+type data struct {
+	BankCode string `fixed:"width=4"`
+	Name     string `fixed:"width=10"`
+	Amount   int64  `fixed:"offset=16,width=8,zero"`
+}
+
+type trailer struct {
+	Count int   `fixed:"width=6,zero"`
+	Total int64 `fixed:"width=10"`
+}
+
+func newLayout(is is.I) *Layout {
+
+	l, err := New(30, "\r\n")
+	is.NoErr(err)
+	is.NoErr(l.Register(CodeHeader, header{}))
+	is.NoErr(l.Register(CodeData, &data{}))
+	is.NoErr(l.Register(CodeTrailer, trailer{}))
+
+	return l
+}
+
+func sjis(s string) string {
+	b, _ := japanese.ShiftJIS.NewEncoder().String(s)
+	return b
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+
+	is := is.New(t)
+
+	l := newLayout(is)
+
+	records := []interface{}{
+		header{Kind: "21", Date: hyutil.Date(2024, 4, 25), BankCode: "0001", BankName: "ﾐｽﾞﾎ"},
+		&data{BankCode: "0005", Name: "ﾔﾏﾀﾞ ﾀﾛｳ", Amount: 12000},
+		data{BankCode: "0009", Name: "山田", Amount: 300},
+		trailer{Count: 2, Total: 12300},
+	}
+
+	b, err := l.Marshal(records)
+	is.NoErr(err)
+	is.Equal(sjis(
+		"1210425"+"0001"+"ﾐｽﾞﾎ      "+"         \r\n"+
+			"2"+"0005"+"ﾔﾏﾀﾞ ﾀﾛｳ  "+" "+"00012000"+"      \r\n"+
+			"2"+"0009"+"山田      "+" "+"00000300"+"      \r\n"+
+			"8"+"000002"+"     12300"+"             \r\n"), string(b))
+
+	ret, err := l.Unmarshal(append(b, 0x1a))
+	is.NoErr(err)
+	is.Equal(4, len(ret))
+	h := ret[0].(*header)
+	is.Equal("21", h.Kind)
+	is.Equal("04/25", h.Date.Format("01/02"))
+	is.Equal("ﾐｽﾞﾎ", h.BankName)
+	is.Equal(&data{BankCode: "0005", Name: "ﾔﾏﾀﾞ ﾀﾛｳ", Amount: 12000}, ret[1])
+	is.Equal(&data{BankCode: "0009", Name: "山田", Amount: 300}, ret[2])
+	is.Equal(&trailer{Count: 2, Total: 12300}, ret[3])
+
+	var d data
+	is.NoErr(l.Decode(b[32:62], &d))
+	is.Equal("0005", d.BankCode)
+}
+
+func TestValidation(t *testing.T) {
+
+	is := is.New(t)
+
+	l := newLayout(is)
+
+	_, err := l.Marshal([]interface{}{
+		data{Name: "ヤマダ タロウ", Amount: 1},
+		data{Name: "😀", Amount: -1},
+	})
+	verr, ok := err.(*ValidationError)
+	is.True(ok)
+	is.Equal(3, len(verr.Errors))
+	is.Equal("1レコード目 Name(6-15) 'ヤマダ タロウ' : 10バイトを超えています（13バイト）", verr.Errors[0].Error())
+	is.Equal("Shift_JISに変換できない文字があります", verr.Errors[1].Reason)
+	is.Equal("負の数は0埋めできません", verr.Errors[2].Reason)
+
+	_, err = l.Marshal([]interface{}{struct{}{}})
+	is.True(strings.HasPrefix(err.Error(), ErrUnregistered.Error()))
+
+	src := "2" + "0005" + "ﾔﾏﾀﾞ      " + " " + "0001200X" + "      \r\n" +
+		"3" + strings.Repeat(" ", 29) + "\r\n" +
+		"8" + "00001" + "\r\n"
+
+	ret, err := l.Unmarshal([]byte(sjis(src)))
+	is.Equal(1, len(ret))
+	is.Equal("固定長レコードが不正です\n"+
+		"1レコード目 Amount(17-24) '0001200X' : 数値ではありません\n"+
+		"2レコード目 : 不明なレコード種別です：3\n"+
+		"3レコード目 : レコード長が30バイトではありません（6バイト）", err.Error())
+}
+
+func TestRegister(t *testing.T) {
+
+	is := is.New(t)
+
+	_, err := New(0, "")
+	is.Equal(ErrRecordLen, err)
+
+	l := &Layout{}
+	is.Equal(ErrRecordLen, l.Register("1", data{}))
+	_, err = l.Unmarshal([]byte("1"))
+	is.Equal(ErrRecordLen, err)
+
+	l, err = New(10, "")
+	is.NoErr(err)
+
+	is.Err(l.Register("1", struct {
+		A string `fixed:"width=5"`
+		B string `fixed:"offset=3,width=2"`
+	}{}))
+	is.Err(l.Register("1", struct {
+		A string `fixed:"width=10"`
+	}{}))
+	is.Err(l.Register("1", struct {
+		A string `fixed:"offset=0,width=2"`
+	}{}))
+	is.Err(l.Register("1", struct {
+		A float64 `fixed:"width=2"`
+	}{}))
+	is.NoErr(l.Register("1", struct {
+		A string `fixed:"width=9,align=right,pad=*"`
+	}{}))
+	is.Err(l.Register("1", data{}))
+}
+
+func TestZeroString(t *testing.T) {
+
+	is := is.New(t)
+
+	type bank struct {
+		BankCode string `fixed:"width=4,zero"`
+		Name     string `fixed:"width=5,align=right"`
+	}
+
+	l, err := New(10, "")
+	is.NoErr(err)
+	is.NoErr(l.Register("2", bank{}))
+
+	for _, code := range []string{"0005", "0000", "1200"} {
+
+		b, err := l.Encode(bank{BankCode: code, Name: "ABC"})
+		is.NoErr(err)
+		is.Equal("2"+code+"  ABC", string(b))
+
+		var v bank
+		is.NoErr(l.Decode(b, &v))
+		is.Equal(code, v.BankCode)
+		is.Equal("ABC", v.Name)
+	}
+}
@@ -0,0 +1,281 @@
+package hyufixed
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gara-snake/hyutil"
+)
+
+// tagName 項目の位置と書式を指定するタグ名です
+//
+//	BankCode string          `fixed:"width=4,zero"`
+//	Name     string          `fixed:"offset=20,width=15"`
+//	Amount   int64           `fixed:"width=10,zero"`
+//	Date     hyutil.DateTime `fixed:"width=4,date=0102"`
+//	Dummy    string          `fixed:"-"`
+//
+// offset レコード先頭からのバイト位置（0始まり）。省略した場合は直前の項目の後ろです
+// width Shift_JISでのバイト数。必須です
+// align left または right。既定値は文字列がleft、数値がrightです
+// pad 埋める文字（1バイト）。既定値は半角空白です
+// zero 0で埋めて右寄せにします
+//
+// 読み込み時、数値は埋めた文字を前後から除きます。文字列は左寄せの場合のみ末尾の埋めた文字を除き、
+// 右寄せの場合は先頭の半角空白だけを除きます（"0005"のような0埋めの値はそのままです）
+// date hyutil.DateTimeの書式です
+const tagName = "fixed"
+
+// 全銀フォーマットのレコード種別（データ区分）
+const (
+	CodeHeader  = "1"
+	CodeData    = "2"
+	CodeTrailer = "8"
+	CodeEnd     = "9"
+)
+
+var dateTimeType = reflect.TypeOf(hyutil.DateTime{})
+
+// Align 寄せ
+type Align int
+
+const (
+	// AlignLeft 左寄せ
+	AlignLeft Align = iota
+	// AlignRight 右寄せ
+	AlignRight
+)
+
+// field 構造体のフィールドとレコード内の位置
+type field struct {
+	name   string
+	index  []int
+	offset int
+	width  int
+	align  Align
+	pad    byte
+	date   string
+}
+
+// recordType レコード種別ごとの構造体
+type recordType struct {
+	code   string
+	tp     reflect.Type
+	fields []field
+}
+
+// Layout 固定長ファイルのレイアウトです。レコード種別ごとに構造体を登録します
+// 各レコードはレコード種別(code)から始まり、項目のない位置と末尾は半角空白で埋めます
+type Layout struct {
+	// RecordLen 1レコードのバイト数
+	RecordLen int
+	// Separator レコードの区切り。空の場合は区切りなしで連続します
+	Separator string
+
+	types []recordType
+}
+
+// ErrRecordLen レコード長が0以下です
+var ErrRecordLen = errors.New("hyufixed:レコード長は1以上を指定してください")
+
+// New レイアウトを作成します。recordLenが0以下の場合はErrRecordLenを返却します
+func New(recordLen int, separator string) (*Layout, error) {
+
+	l := &Layout{
+		RecordLen: recordLen,
+		Separator: separator,
+	}
+
+	if err := l.checkLen(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// checkLen レコード長が1以上か。RecordLenは公開されているため読み書きの前にも確認します
+func (l *Layout) checkLen() error {
+
+	if l.RecordLen <= 0 {
+		return ErrRecordLen
+	}
+
+	return nil
+}
+
+// Register レコード種別codeのレコードとしてsampleの構造体の型を登録します
+func (l *Layout) Register(code string, sample interface{}) error {
+
+	if err := l.checkLen(); err != nil {
+		return err
+	}
+
+	if code == "" {
+		return errors.New("hyufixed:レコード種別がありません")
+	}
+
+	for _, rt := range l.types {
+		if strings.HasPrefix(rt.code, code) || strings.HasPrefix(code, rt.code) {
+			return errors.New("hyufixed:レコード種別が重複しています：" + code)
+		}
+	}
+
+	tp := reflect.TypeOf(sample)
+
+	if tp != nil && tp.Kind() == reflect.Ptr {
+		tp = tp.Elem()
+	}
+
+	if tp == nil || tp.Kind() != reflect.Struct {
+		return errors.New("hyufixed:構造体ではありません")
+	}
+
+	fields, err := parseFields(tp, len(code), l.RecordLen)
+
+	if err != nil {
+		return err
+	}
+
+	l.types = append(l.types, recordType{code: code, tp: tp, fields: fields})
+
+	return nil
+}
+
+// parseFields タグから項目の位置を決定し、重なりとレコード長を確認します
+func parseFields(tp reflect.Type, start int, recordLen int) ([]field, error) {
+
+	ret := make([]field, 0, tp.NumField())
+	next := start
+
+	for i := 0; i < tp.NumField(); i++ {
+
+		sf := tp.Field(i)
+		tag := sf.Tag.Get(tagName)
+
+		if tag == "" || tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		f := field{
+			name:   sf.Name,
+			index:  sf.Index,
+			offset: -1,
+			pad:    ' ',
+		}
+
+		switch sf.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			f.align = AlignRight
+		case reflect.String:
+		default:
+			if sf.Type != dateTimeType {
+				return nil, fmt.Errorf("hyufixed:%s の型には対応していません：%s", sf.Name, sf.Type)
+			}
+		}
+
+		for _, opt := range strings.Split(tag, ",") {
+
+			name, val, _ := strings.Cut(opt, "=")
+
+			var err error
+
+			switch name {
+			case "offset":
+				f.offset, err = strconv.Atoi(val)
+			case "width":
+				f.width, err = strconv.Atoi(val)
+			case "align":
+				switch val {
+				case "left":
+					f.align = AlignLeft
+				case "right":
+					f.align = AlignRight
+				default:
+					err = errors.New(val)
+				}
+			case "pad":
+				if len(val) != 1 {
+					err = errors.New(val)
+				} else {
+					f.pad = val[0]
+				}
+			case "zero":
+				f.pad = '0'
+				f.align = AlignRight
+			case "date":
+				f.date = val
+			default:
+				err = errors.New(opt)
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("hyufixed:%s のタグが不正です：%s", sf.Name, opt)
+			}
+		}
+
+		if f.offset < 0 {
+			f.offset = next
+		}
+
+		if f.width <= 0 {
+			return nil, fmt.Errorf("hyufixed:%s のwidthがありません", sf.Name)
+		}
+
+		if sf.Type == dateTimeType && f.date == "" {
+			return nil, fmt.Errorf("hyufixed:%s のdateがありません", sf.Name)
+		}
+
+		if f.offset < start {
+			return nil, fmt.Errorf("hyufixed:%s がレコード種別と重なっています", sf.Name)
+		}
+
+		if recordLen < f.offset+f.width {
+			return nil, fmt.Errorf("hyufixed:%s がレコード長を超えています", sf.Name)
+		}
+
+		next = f.offset + f.width
+		ret = append(ret, f)
+	}
+
+	sorted := make([]field, len(ret))
+	copy(sorted, ret)
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].offset < sorted[j].offset })
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].offset < sorted[i-1].offset+sorted[i-1].width {
+			return nil, fmt.Errorf("hyufixed:%s と %s が重なっています", sorted[i-1].name, sorted[i].name)
+		}
+	}
+
+	return ret, nil
+}
+
+// typeOf 値の型のレコード種別
+func (l *Layout) typeOf(tp reflect.Type) (*recordType, bool) {
+
+	for i := range l.types {
+		if l.types[i].tp == tp {
+			return &l.types[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// typeOfRecord レコードの先頭のレコード種別
+func (l *Layout) typeOfRecord(rec []byte) (*recordType, bool) {
+
+	for i := range l.types {
+		if strings.HasPrefix(string(rec), l.types[i].code) {
+			return &l.types[i], true
+		}
+	}
+
+	return nil, false
+}
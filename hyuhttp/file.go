@@ -2,6 +2,7 @@ package hyuhttp
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 )
@@ -48,3 +49,13 @@ func DownloadFile(f *FileData, w http.ResponseWriter, r *http.Request) {
 	w.Write(f.Bin)
 
 }
+
+// DownloadStream writeで逐次書き込みながらダウンロード"させる"。全体をメモリに持たないためContent-Lengthは送信しません
+func DownloadStream(name string, w http.ResponseWriter, r *http.Request, write func(w io.Writer) error) error {
+
+	w.Header().Set("Content-Disposition", "attachment; filename="+name)
+	w.Header().Set("Content-Type", GetContentType(&FileData{Name: name}))
+
+	return write(w)
+
+}
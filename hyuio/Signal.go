@@ -4,12 +4,26 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 )
 
+var (
+	quitMu    sync.Mutex
+	quitHooks []func()
+)
+
+// OnQuit WaitQuitで終了する直前に実行する処理を登録します。登録と逆の順番で実行されます
+func OnQuit(fn func()) {
+	quitMu.Lock()
+	defer quitMu.Unlock()
+
+	quitHooks = append(quitHooks, fn)
+}
+
 // WaitQuit Ctrl + C での終了を待機する
 func WaitQuit() {
 
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 
 	go func() {
@@ -17,8 +31,20 @@ func WaitQuit() {
 		case <-quit:
 			log.Println("Good luck !")
 			signal.Stop(quit)
+			runQuitHooks()
 			os.Exit(0)
 		}
 	}()
 
 }
+
+func runQuitHooks() {
+	quitMu.Lock()
+	hooks := quitHooks
+	quitHooks = nil
+	quitMu.Unlock()
+
+	for i := len(hooks) - 1; 0 <= i; i-- {
+		hooks[i]()
+	}
+}
@@ -0,0 +1,522 @@
+package hyuxlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gara-snake/hyutil"
+	"github.com/gara-snake/hyutil/hyucsv"
+)
+
+// ErrNoSheet ワークブックにシートがありません
+var ErrNoSheet = errors.New("hyuxlsx:シートがありません")
+
+// ErrTooLarge ファイル、展開後のXML、またはセルの数が上限を超えています
+var ErrTooLarge = errors.New("hyuxlsx:ファイルが大きすぎます")
+
+// MaxSize 読み込むxlsxファイルと、展開後の各XMLの最大バイト数
+var MaxSize int64 = 100 << 20
+
+// MaxCells 読み込むセルの最大数。列を揃えるために補う空のセルを含みます
+var MaxCells = 5000000
+
+// シートの最大の列数(XFD)と行数
+const (
+	maxColumns = 16384
+	maxRows    = 1048576
+)
+
+// Read xlsxファイルの最初のシートを読み込み、Csvデータを作成します
+// 1行目をヘッダーとし、照合はhyucsv.Readと同じです。日付のセルはhyutil.DateTimeの書式の文字列になります
+// 途中の空の行も空の値の行として読み込むため、Rowsのi番目（と行番号）はシートのi+2行目です。末尾の空の行は読み込みません
+func Read(name string, fields []hyucsv.CsvField, r io.Reader, opt hyucsv.ReadOptions) (*hyucsv.Csv, error) {
+
+	b, err := io.ReadAll(io.LimitReader(r, MaxSize+1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if MaxSize < int64(len(b)) {
+		return nil, ErrTooLarge
+	}
+
+	records, err := ReadRecords(bytes.NewReader(b), int64(len(b)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	// 書式だけが設定された末尾の空の行を除く
+	for 0 < len(records) && strings.Join(records[len(records)-1], "") == "" {
+		records = records[:len(records)-1]
+	}
+
+	// ヘッダーの照合をhyucsvと揃えるためCSVにして読み込む
+	var buf bytes.Buffer
+
+	w := csv.NewWriter(&buf)
+
+	// ヘッダーより右の列は照合されないため、列数はヘッダーに揃える
+	cols := 1
+
+	if 0 < len(records) && cols < len(records[0]) {
+		cols = len(records[0])
+	}
+
+	if MaxCells < len(records)*cols {
+		return nil, ErrTooLarge
+	}
+
+	for _, rec := range records {
+
+		// 列数を揃える。空の行も行番号を保つため書き込む
+		if cols < len(rec) {
+			rec = rec[:cols]
+		}
+
+		for len(rec) < cols {
+			rec = append(rec, "")
+		}
+
+		// 1列の空の値は空行になり読み飛ばされるため、""で書き込む
+		if cols == 1 && rec[0] == "" {
+			w.Flush()
+			buf.WriteString("\"\"\n")
+			continue
+		}
+
+		if err := w.Write(rec); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	opt.Encoding = hyucsv.EncodingUTF8
+	opt.Dialect = hyucsv.Dialect{}
+
+	c, err := hyucsv.Read(name, fields, &buf, opt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.Name = strings.TrimSuffix(strings.TrimSuffix(name, ".csv"), extension) + extension
+	// 変換したCSVの行番号は改行を含むセルでずれるため、シートの行番号(i+2)にする
+	c.Lines = nil
+
+	return c, nil
+}
+
+// ReadRecords xlsxファイルの最初のシートを文字列の2次元配列で返却します。空のセルは空文字です
+func ReadRecords(r io.ReaderAt, size int64) ([][]string, error) {
+
+	zr, err := zip.NewReader(r, size)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheet, err := firstSheet(files)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var shared []string
+
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if shared, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+
+	var dateStyles map[int]bool
+
+	if f, ok := files["xl/styles.xml"]; ok {
+		if dateStyles, err = readDateStyles(f); err != nil {
+			return nil, err
+		}
+	}
+
+	f, ok := files[sheet]
+
+	if !ok {
+		return nil, ErrNoSheet
+	}
+
+	return readSheet(f, shared, dateStyles)
+}
+
+func decodeXML(f *zip.File, v interface{}) error {
+
+	rc, err := f.Open()
+
+	if err != nil {
+		return err
+	}
+
+	defer rc.Close()
+
+	if uint64(MaxSize) < f.UncompressedSize64 {
+		return ErrTooLarge
+	}
+
+	// UncompressedSize64は偽装できるため、展開したバイト数でも制限する
+	return xml.NewDecoder(&limitReader{r: rc, n: MaxSize}).Decode(v)
+}
+
+// limitReader nバイトを超えて読み込むとErrTooLargeを返却します
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+
+	// 上限を超える1バイトまで読み込み、超えた場合はエラーにする
+	if l.n+1 < int64(len(p)) {
+		p = p[:l.n+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+
+	if l.n < 0 {
+		return n, ErrTooLarge
+	}
+
+	return n, err
+}
+
+// firstSheet workbook.xmlの最初のシートのパス
+func firstSheet(files map[string]*zip.File) (string, error) {
+
+	wf, ok := files["xl/workbook.xml"]
+
+	if !ok {
+		return "", ErrNoSheet
+	}
+
+	var wb struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+
+	if err := decodeXML(wf, &wb); err != nil {
+		return "", err
+	}
+
+	if len(wb.Sheets) == 0 {
+		return "", ErrNoSheet
+	}
+
+	rf, ok := files["xl/_rels/workbook.xml.rels"]
+
+	if !ok {
+		return "xl/worksheets/sheet1.xml", nil
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	if err := decodeXML(rf, &rels); err != nil {
+		return "", err
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID == wb.Sheets[0].ID {
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+	}
+
+	return "", ErrNoSheet
+}
+
+// richText 文字列のセル。ふりがな(rPh)は含めません
+type richText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (rt *richText) String() string {
+
+	if len(rt.Runs) == 0 {
+		return rt.T
+	}
+
+	var b strings.Builder
+
+	for _, r := range rt.Runs {
+		b.WriteString(r.T)
+	}
+
+	return b.String()
+}
+
+func readSharedStrings(f *zip.File) ([]string, error) {
+
+	var sst struct {
+		Items []richText `xml:"si"`
+	}
+
+	if err := decodeXML(f, &sst); err != nil {
+		return nil, err
+	}
+
+	ret := make([]string, 0, len(sst.Items))
+
+	for i := range sst.Items {
+		ret = append(ret, sst.Items[i].String())
+	}
+
+	return ret, nil
+}
+
+// readDateStyles 日付の表示形式のスタイル番号
+func readDateStyles(f *zip.File) (map[int]bool, error) {
+
+	var ss struct {
+		NumFmts []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		CellXfs []struct {
+			NumFmtID int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+
+	if err := decodeXML(f, &ss); err != nil {
+		return nil, err
+	}
+
+	custom := make(map[int]bool, len(ss.NumFmts))
+
+	for _, nf := range ss.NumFmts {
+		custom[nf.ID] = isDateFormat(nf.Code)
+	}
+
+	ret := make(map[int]bool)
+
+	for i, xf := range ss.CellXfs {
+
+		date, ok := custom[xf.NumFmtID]
+
+		if !ok {
+			date = isBuiltinDate(xf.NumFmtID)
+		}
+
+		if date {
+			ret[i] = true
+		}
+	}
+
+	return ret, nil
+}
+
+// isBuiltinDate 組み込みの表示形式のうち日付、時刻のもの（日本語環境の27-36, 50-58を含む）
+func isBuiltinDate(id int) bool {
+	return (14 <= id && id <= 22) || (27 <= id && id <= 36) || (45 <= id && id <= 47) || (50 <= id && id <= 58)
+}
+
+// isDateFormat 表示形式の文字列が日付、時刻か。""で囲んだ文字列、[]の色や条件は除いて判定します
+func isDateFormat(code string) bool {
+
+	var b strings.Builder
+
+	quoted, bracket := false, false
+
+	for _, r := range code {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '[':
+			bracket = true
+		case r == ']':
+			bracket = false
+		case bracket:
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	s := strings.ToLower(b.String())
+
+	if s == "general" {
+		return false
+	}
+
+	return strings.ContainsAny(s, "ymdhs")
+}
+
+func readSheet(f *zip.File, shared []string, dateStyles map[int]bool) ([][]string, error) {
+
+	var ws struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R  string   `xml:"r,attr"`
+				S  int      `xml:"s,attr"`
+				T  string   `xml:"t,attr"`
+				V  string   `xml:"v"`
+				Is richText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+
+	if err := decodeXML(f, &ws); err != nil {
+		return nil, err
+	}
+
+	ret := make([][]string, 0, len(ws.Rows))
+	cells := 0
+
+	for _, row := range ws.Rows {
+
+		if maxRows < row.R || maxRows <= len(ret) {
+			return nil, fmt.Errorf("hyuxlsx:行番号が不正です：%d", row.R)
+		}
+
+		// 省略された空の行を補う
+		for 0 < row.R && len(ret) < row.R-1 {
+			ret = append(ret, []string{})
+		}
+
+		record := make([]string, 0, len(row.Cells))
+
+		for _, c := range row.Cells {
+
+			col := len(record)
+
+			if c.R != "" {
+				if n, ok := columnIndex(c.R); ok {
+					col = n
+				}
+			}
+
+			if maxColumns <= col {
+				return nil, errors.New("hyuxlsx:セル参照が不正です：" + c.R)
+			}
+
+			if len(record) <= col {
+
+				if cells += col + 1 - len(record); MaxCells < cells {
+					return nil, ErrTooLarge
+				}
+
+				for len(record) <= col {
+					record = append(record, "")
+				}
+			}
+
+			var val string
+
+			switch c.T {
+			case "s":
+				i, err := strconv.Atoi(c.V)
+				if err != nil || i < 0 || len(shared) <= i {
+					return nil, errors.New("hyuxlsx:共有文字列が不正です：" + c.R)
+				}
+				val = shared[i]
+			case "inlineStr":
+				val = c.Is.String()
+			case "b", "str", "e":
+				val = c.V
+			default:
+				val = numberValue(c.V, dateStyles[c.S])
+			}
+
+			record[col] = val
+		}
+
+		ret = append(ret, record)
+	}
+
+	return ret, nil
+}
+
+// numberValue 数値のセルの値。日付の場合はhyutil.DateTimeの書式にします
+func numberValue(v string, date bool) string {
+
+	if v == "" {
+		return ""
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+
+	if err != nil {
+		return v
+	}
+
+	if date {
+		return fromSerial(f).Format(hyutil.DateTimeFormat)
+	}
+
+	// 浮動小数点の誤差を表示上の桁数(15桁)で丸める
+	f, _ = strconv.ParseFloat(strconv.FormatFloat(f, 'g', 15, 64), 64)
+
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// fromSerial Excelのシリアル値をローカル時間の日時にします
+func fromSerial(f float64) time.Time {
+
+	days := math.Floor(f)
+	sec := math.Round((f - days) * 86400)
+
+	t := epoch.AddDate(0, 0, int(days)).Add(time.Duration(sec) * time.Second)
+
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+}
+
+// columnIndex A1形式のセル参照から0始まりの列番号を返却します
+// 最大の列(XFD)を超える場合は桁あふれしないようmaxColumnsで打ち切ります
+func columnIndex(ref string) (int, bool) {
+
+	n := 0
+	i := 0
+
+	for ; i < len(ref) && 'A' <= ref[i] && ref[i] <= 'Z'; i++ {
+		if n <= maxColumns {
+			n = n*26 + int(ref[i]-'A'+1)
+		}
+	}
+
+	if i == 0 {
+		return 0, false
+	}
+
+	return n - 1, true
+}
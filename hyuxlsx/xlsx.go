@@ -0,0 +1,399 @@
+package hyuxlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gara-snake/hyutil"
+	"github.com/gara-snake/hyutil/hyucsv"
+	"golang.org/x/text/width"
+)
+
+const extension = ".xlsx"
+
+// CellType セルの型
+type CellType int
+
+const (
+	// CellAuto 値から判定します。hyutil.DateTimeの書式は日付、数値は数値、それ以外は文字列です
+	CellAuto CellType = iota
+	// CellString 文字列
+	CellString
+	// CellNumber 数値。数値でない場合は文字列になります
+	CellNumber
+	// CellDate 日付。時刻がある場合は日時になります。日付でない場合は文字列になります
+	CellDate
+)
+
+// Options 書き込みの設定
+type Options struct {
+	// SheetName シート名。既定値は"Sheet1"です
+	SheetName string
+	// Header ヘッダー行の形式。hyucsv.CreateTypeLabelの場合はラベルのみです
+	Header hyucsv.CreateType
+	// Types CsvFieldのKeyごとのセルの型
+	Types map[string]CellType
+	// Widths CsvFieldのKeyごとの列幅（文字数）。指定のない列は内容から決定します
+	Widths map[string]float64
+}
+
+// スタイル。styles.xmlのcellXfsの順番です
+const (
+	styleDefault = iota
+	styleHeader
+	styleDate
+	styleDateTime
+)
+
+const (
+	minWidth = 8
+	maxWidth = 60
+)
+
+// number 先頭が0の数字（郵便番号、電話番号など）は文字列のままにする
+var number = regexp.MustCompile(`^-?(0|[1-9][0-9]{0,14})(\.[0-9]+)?$`)
+
+// Name ファイル名の拡張子を.xlsxにします
+func Name(c *hyucsv.Csv) string {
+	return strings.TrimSuffix(c.Name, ".csv") + extension
+}
+
+// Buffer xlsxファイルを内包した bytes.Buffer
+func Buffer(c *hyucsv.Csv, opt Options) (*bytes.Buffer, error) {
+
+	var buf bytes.Buffer
+
+	if err := Write(&buf, c, opt); err != nil {
+		return nil, err
+	}
+
+	return &buf, nil
+}
+
+// Write Csvを1シートのxlsxファイルとして書き込みます
+// ヘッダー行は太字で固定表示にし、値はOptions.Typesに従って数値、日付のセルにします
+func Write(w io.Writer, c *hyucsv.Csv, opt Options) error {
+
+	sheetName := opt.SheetName
+	if sheetName == "" {
+		sheetName = "Sheet1"
+	}
+
+	zw := zip.NewWriter(w)
+
+	parts := []struct {
+		name string
+		body string
+	}{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", workbook(sheetName)},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+		{"xl/styles.xml", styles},
+	}
+
+	for _, p := range parts {
+
+		f, err := zw.Create(p.name)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+
+	if err != nil {
+		return err
+	}
+
+	if err := writeSheet(f, c, opt); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func writeSheet(w io.Writer, c *hyucsv.Csv, opt Options) error {
+
+	var b strings.Builder
+
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0">`)
+	b.WriteString(`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`)
+	b.WriteString(`</sheetView></sheetViews>`)
+
+	header := make([]string, 0, len(c.Fields))
+
+	for _, f := range c.Fields {
+		if opt.Header == hyucsv.CreateTypeLabel {
+			header = append(header, f.Label)
+		} else {
+			header = append(header, f.String())
+		}
+	}
+
+	if 0 < len(c.Fields) {
+
+		b.WriteString(`<cols>`)
+
+		for i, f := range c.Fields {
+
+			wd, ok := opt.Widths[f.Key]
+
+			if !ok {
+				wd = columnWidth(header[i], c.Rows, f.Key)
+			}
+
+			n := strconv.Itoa(i + 1)
+			b.WriteString(`<col min="` + n + `" max="` + n + `" width="` + strconv.FormatFloat(wd, 'f', -1, 64) + `" customWidth="1"/>`)
+		}
+
+		b.WriteString(`</cols>`)
+	}
+
+	b.WriteString(`<sheetData>`)
+
+	b.WriteString(`<row r="1">`)
+
+	for i, h := range header {
+		writeString(&b, cellRef(i, 1), h, styleHeader)
+	}
+
+	b.WriteString(`</row>`)
+
+	for ri, row := range c.Rows {
+
+		r := ri + 2
+
+		b.WriteString(`<row r="` + strconv.Itoa(r) + `">`)
+
+		for i, f := range c.Fields {
+
+			val, ok := row[f.Key]
+
+			if !ok || val == "" {
+				continue
+			}
+
+			writeCell(&b, cellRef(i, r), val, opt.Types[f.Key])
+		}
+
+		b.WriteString(`</row>`)
+
+		// 行数が多い場合に備えて適宜書き出す
+		if 1<<20 < b.Len() {
+			if _, err := io.WriteString(w, b.String()); err != nil {
+				return err
+			}
+			b.Reset()
+		}
+	}
+
+	b.WriteString(`</sheetData></worksheet>`)
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func writeCell(b *strings.Builder, ref string, val string, tp CellType) {
+
+	if tp == CellAuto || tp == CellNumber {
+		if number.MatchString(val) {
+			b.WriteString(`<c r="` + ref + `"><v>` + val + `</v></c>`)
+			return
+		}
+	}
+
+	if tp == CellAuto || tp == CellDate {
+		if t, ok := parseDate(val, tp == CellDate); ok {
+
+			style := styleDateTime
+			if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+				style = styleDate
+			}
+
+			b.WriteString(`<c r="` + ref + `" s="` + strconv.Itoa(style) + `"><v>` + strconv.FormatFloat(serial(t), 'f', -1, 64) + `</v></c>`)
+			return
+		}
+	}
+
+	writeString(b, ref, val, styleDefault)
+}
+
+func writeString(b *strings.Builder, ref string, val string, style int) {
+
+	b.WriteString(`<c r="` + ref + `" t="inlineStr"`)
+
+	if style != styleDefault {
+		b.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+
+	b.WriteString(`><is><t xml:space="preserve">`)
+	xml.EscapeText(b, []byte(val))
+	b.WriteString(`</t></is></c>`)
+}
+
+// parseDate hyutil.DateTimeの文字列化表現を日付にします。anyの場合はhyutil.DatetimeParseの書式も受け付けます
+func parseDate(val string, any bool) (time.Time, bool) {
+
+	if t, err := time.Parse(hyutil.DateTimeFormat, val); err == nil {
+		return t, true
+	}
+
+	if any {
+		if dt := hyutil.DatetimeParse(val); dt != hyutil.DateTimeZero {
+			return *dt.Time, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// epoch Excelの日付のシリアル値の起点
+var epoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// serial 日時をExcelのシリアル値にします。タイムゾーンは値の表示上の日時をそのまま使用します
+func serial(t time.Time) float64 {
+
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	days := day.Sub(epoch).Hours() / 24
+	sec := t.Hour()*3600 + t.Minute()*60 + t.Second()
+
+	return days + float64(sec)/86400
+}
+
+// cellRef 0始まりの列番号と1始まりの行番号からA1形式のセル参照を作成します
+func cellRef(col int, row int) string {
+	return columnName(col) + strconv.Itoa(row)
+}
+
+func columnName(col int) string {
+
+	name := ""
+
+	for col++; 0 < col; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+
+	return name
+}
+
+// columnWidth 全角文字を2文字として内容の最大幅から列幅を決定します
+func columnWidth(header string, rows []hyucsv.CsvRow, key string) float64 {
+
+	max := textWidth(header)
+
+	for _, r := range rows {
+		if n := textWidth(r[key]); max < n {
+			max = n
+		}
+	}
+
+	wd := float64(max + 2)
+
+	if wd < minWidth {
+		return minWidth
+	}
+
+	if maxWidth < wd {
+		return maxWidth
+	}
+
+	return wd
+}
+
+func textWidth(s string) int {
+
+	n := 0
+
+	for len(s) > 0 {
+
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianWide, width.EastAsianFullwidth:
+			n += 2
+		default:
+			n++
+		}
+	}
+
+	return n
+}
+
+func workbook(sheetName string) string {
+
+	var b strings.Builder
+
+	xml.EscapeText(&b, []byte(sheetName))
+
+	return xml.Header +
+		`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + b.String() + `" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+}
+
+const contentTypes = xml.Header +
+	`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const rootRels = xml.Header +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const workbookRels = xml.Header +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// styles cellXfsはstyleDefault, styleHeader, styleDate, styleDateTimeの順
+const styles = xml.Header +
+	`<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2">` +
+	`<numFmt numFmtId="164" formatCode="yyyy/mm/dd"/>` +
+	`<numFmt numFmtId="165" formatCode="yyyy/mm/dd hh:mm:ss"/>` +
+	`</numFmts>` +
+	`<fonts count="2">` +
+	`<font><sz val="11"/><name val="游ゴシック"/><family val="3"/><charset val="128"/></font>` +
+	`<font><b/><sz val="11"/><name val="游ゴシック"/><family val="3"/><charset val="128"/></font>` +
+	`</fonts>` +
+	`<fills count="3">` +
+	`<fill><patternFill patternType="none"/></fill>` +
+	`<fill><patternFill patternType="gray125"/></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFD9E1F2"/><bgColor indexed="64"/></patternFill></fill>` +
+	`</fills>` +
+	`<borders count="2">` +
+	`<border><left/><right/><top/><bottom/><diagonal/></border>` +
+	`<border><left/><right/><top/><bottom style="thin"><color auto="1"/></bottom><diagonal/></border>` +
+	`</borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="2" borderId="1" xfId="0" applyFont="1" applyFill="1" applyBorder="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
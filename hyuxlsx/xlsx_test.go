@@ -0,0 +1,215 @@
+package hyuxlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+	"github.com/gara-snake/hyutil"
+	"github.com/gara-snake/hyutil/hyucsv"
+)
+
+var testFields = []hyucsv.CsvField{
+	{Key: "id", Label: "ID"},
+	{Key: "name", Label: "氏名"},
+	{Key: "zip", Label: "郵便番号"},
+	{Key: "birth", Label: "生年月日"},
+}
+
+func zipPart(is is.I, b []byte, name string) string {
+
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	is.NoErr(err)
+
+	for _, f := range zr.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			is.NoErr(err)
+			defer rc.Close()
+			body, err := io.ReadAll(rc)
+			is.NoErr(err)
+			return string(body)
+		}
+	}
+
+	return ""
+}
+
+func TestWriteRead(t *testing.T) {
+
+	is := is.New(t)
+
+	birth := hyutil.Date(1990, 4, 1)
+
+	c := &hyucsv.Csv{
+		Name:   "社員.csv",
+		Fields: testFields,
+		Rows: []hyucsv.CsvRow{
+			{"id": "1", "name": "山田 <太郎>", "zip": "0010001", "birth": birth.String()},
+			{"id": "2", "name": "鈴木", "zip": "1000001"},
+		},
+	}
+
+	buf, err := Buffer(c, Options{Header: hyucsv.CreateTypeLabel, Types: map[string]CellType{"zip": CellString}})
+	is.NoErr(err)
+	is.Equal("社員.xlsx", Name(c))
+
+	sheet := zipPart(is, buf.Bytes(), "xl/worksheets/sheet1.xml")
+	is.True(strings.Contains(sheet, `<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`))
+	is.True(strings.Contains(sheet, `<c r="A2"><v>1</v></c>`))
+	is.True(strings.Contains(sheet, `<c r="B2" t="inlineStr"><is><t xml:space="preserve">山田 &lt;太郎&gt;</t></is></c>`))
+	is.True(strings.Contains(sheet, `<c r="C3" t="inlineStr"><is><t xml:space="preserve">1000001</t></is></c>`))
+	is.True(strings.Contains(sheet, `<c r="D2" s="2"><v>32964</v></c>`))
+	is.True(strings.Contains(sheet, `<col min="2" max="2" width="13" customWidth="1"/>`))
+
+	r, err := Read("社員.xlsx", testFields, bytes.NewReader(buf.Bytes()), hyucsv.ReadOptions{Type: hyucsv.CreateTypeLabel})
+	is.NoErr(err)
+	is.Equal("社員.xlsx", r.Name)
+	is.Equal(2, len(r.Rows))
+	is.Equal("山田 <太郎>", r.Rows[0]["name"])
+	is.Equal("0010001", r.Rows[0]["zip"])
+	is.Equal(birth.String(), r.Rows[0]["birth"])
+	is.Equal("", r.Rows[1]["birth"])
+}
+
+// zipParts パス、内容のxlsxファイルを作成します
+func zipParts(is is.I, parts map[string]string) *bytes.Buffer {
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	for name, body := range parts {
+		f, err := zw.Create(name)
+		is.NoErr(err)
+		_, err = io.WriteString(f, body)
+		is.NoErr(err)
+	}
+
+	is.NoErr(zw.Close())
+
+	return &buf
+}
+
+// sheetParts 1枚目のシートのsheetDataを指定したxlsxファイルの内容
+func sheetParts(sheetData string) map[string]string {
+	return map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheets><sheet name="Sheet1" sheetId="1"/></sheets></workbook>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			sheetData + `</sheetData></worksheet>`,
+	}
+}
+
+func TestReadSharedStrings(t *testing.T) {
+
+	is := is.New(t)
+
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="一覧" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId3" Type="worksheet" Target="worksheets/list.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>氏名</t></si>` +
+			`<si><r><t>山田</t></r><r><t>太郎</t></r><rPh sb="0" eb="2"><t>ヤマダ</t></rPh></si>` +
+			`<si><t>Ｉｄ</t></si></sst>`,
+		"xl/styles.xml": `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<numFmts><numFmt numFmtId="170" formatCode="[$-ja-JP]ggge&quot;年&quot;m&quot;月&quot;d&quot;日&quot;"/></numFmts>` +
+			`<cellXfs><xf numFmtId="0"/><xf numFmtId="170"/><xf numFmtId="4"/></cellXfs></styleSheet>`,
+		"xl/worksheets/list.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>2</v></c><c r="C1" t="s"><v>0</v></c><c r="D1" t="inlineStr"><is><t>生年月日</t></is></c></row>` +
+			`<row r="3"><c r="A3"><v>1</v></c><c r="C3" t="s"><v>1</v></c><c r="D3" s="1"><v>32964.5</v></c></row>` +
+			`<row r="4"><c r="A4" s="2"><v>0.30000000000000004</v></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+
+	c, err := Read("test", testFields, zipParts(is, parts), hyucsv.ReadOptions{Type: hyucsv.CreateTypeAny, Normalize: true})
+	is.NoErr(err)
+	// 空の2行目も読み込み、Rowsのi番目はシートのi+2行目
+	is.Equal(3, len(c.Rows))
+	is.Equal("", c.Rows[0]["id"])
+	is.Equal("1", c.Rows[1]["id"])
+	is.Equal("山田太郎", c.Rows[1]["name"])
+	is.Equal("12:00:00", hyutil.DatetimeParse(c.Rows[1]["birth"]).Format("15:04:05"))
+	is.Equal("0.3", c.Rows[2]["id"])
+}
+
+func TestReadLine(t *testing.T) {
+
+	is := is.New(t)
+
+	buf := zipParts(is, sheetParts(`<row r="1"><c r="A1" t="inlineStr"><is><t>ID</t></is></c></row>`+
+		`<row r="2"><c r="A2" t="inlineStr"><is><t>1
+</t></is></c></row>`+
+		`<row r="5"><c r="A5" t="inlineStr"><is><t>x</t></is></c></row>`+
+		`<row r="6"><c r="A6" s="1"/></row>`))
+
+	c, err := Read("test", testFields[:1], buf, hyucsv.ReadOptions{Type: hyucsv.CreateTypeAny})
+	is.NoErr(err)
+	is.Equal(4, len(c.Rows))
+
+	var v struct {
+		ID int `json:"id"`
+	}
+
+	errs := c.DecodeRow(3, &v)
+	is.Equal(1, len(errs))
+	is.Equal(5, errs[0].Line)
+}
+
+func TestReadLimits(t *testing.T) {
+
+	is := is.New(t)
+
+	b := zipParts(is, sheetParts(`<row r="1"><c r="XFD1"><v>1</v></c></row>`)).Bytes()
+	rec, err := ReadRecords(bytes.NewReader(b), int64(len(b)))
+	is.NoErr(err)
+	is.Equal(16384, len(rec[0]))
+
+	b = zipParts(is, sheetParts(`<row r="1"><c r="XFE1"><v>1</v></c></row>`)).Bytes()
+	_, err = ReadRecords(bytes.NewReader(b), int64(len(b)))
+	is.Equal("hyuxlsx:セル参照が不正です：XFE1", err.Error())
+
+	b = zipParts(is, sheetParts(`<row r="1"><c r="AAAAAAAAAAAAAAAAAAAA1"><v>1</v></c></row>`)).Bytes()
+	_, err = ReadRecords(bytes.NewReader(b), int64(len(b)))
+	is.Equal("hyuxlsx:セル参照が不正です：AAAAAAAAAAAAAAAAAAAA1", err.Error())
+
+	b = zipParts(is, sheetParts(`<row r="1048577"><c><v>1</v></c></row>`)).Bytes()
+	_, err = ReadRecords(bytes.NewReader(b), int64(len(b)))
+	is.Equal("hyuxlsx:行番号が不正です：1048577", err.Error())
+
+	defer func(n int) { MaxCells = n }(MaxCells)
+	defer func(n int64) { MaxSize = n }(MaxSize)
+
+	MaxCells = 20000
+	b = zipParts(is, sheetParts(`<row r="1"><c r="XFD1"><v>1</v></c></row><row r="2"><c r="XFD2"><v>1</v></c></row>`)).Bytes()
+	_, err = ReadRecords(bytes.NewReader(b), int64(len(b)))
+	is.Equal(ErrTooLarge, err)
+
+	b = zipParts(is, sheetParts(strings.Repeat(`<row><c><v>1</v></c></row>`, 100))).Bytes()
+	_, err = ReadRecords(bytes.NewReader(b), int64(len(b)))
+	is.NoErr(err)
+
+	MaxSize = 1000
+	_, err = ReadRecords(bytes.NewReader(b), int64(len(b)))
+	is.Equal(ErrTooLarge, err)
+
+	MaxSize = 100
+	_, err = Read("test", testFields, bytes.NewReader(b), hyucsv.ReadOptions{})
+	is.Equal(ErrTooLarge, err)
+}
+
+func TestColumnName(t *testing.T) {
+
+	is := is.New(t)
+
+	for col, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"} {
+		is.Equal(name, columnName(col))
+		n, ok := columnIndex(name + "12")
+		is.True(ok)
+		is.Equal(col, n)
+	}
+}
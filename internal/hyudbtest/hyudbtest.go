@@ -0,0 +1,183 @@
+// Package hyudbtest はhyudbと関連パッケージのテストで使用する、クエリごとに結果を返却するドライバーです
+package hyudbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Connector 実行されたクエリを記録し、Exec、Queryで結果を返却するドライバーです
+// sql.OpenDBで使用するか、Registerで登録してsql.Openで使用します
+type Connector struct {
+	// Exec INSERT、UPDATE、DELETEなどの結果。nilの場合は1行更新です
+	Exec func(query string, args []driver.NamedValue) (driver.Result, error)
+	// Query SELECTの結果。nilの場合は0行です
+	Query func(query string, args []driver.NamedValue) (*Rows, error)
+
+	mu      sync.Mutex
+	queries []string
+	args    [][]driver.NamedValue
+	closed  int
+}
+
+// Register nameでcを登録します
+func Register(name string, c *Connector) {
+	sql.Register(name, c)
+}
+
+// Connect driver.Connectorの実装です
+func (c *Connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{c: c}, nil
+}
+
+// Driver driver.Connectorの実装です
+func (c *Connector) Driver() driver.Driver {
+	return c
+}
+
+// Open driver.Driverの実装です
+func (c *Connector) Open(name string) (driver.Conn, error) {
+	return &conn{c: c}, nil
+}
+
+// Queries 実行されたクエリ。コミットとロールバックは"COMMIT"、"ROLLBACK"です
+func (c *Connector) Queries() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.queries...)
+}
+
+// Args 実行されたクエリの引数。Queriesと同じ順です
+func (c *Connector) Args() [][]driver.NamedValue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]driver.NamedValue{}, c.args...)
+}
+
+// Closed 閉じられた接続の数
+func (c *Connector) Closed() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// Reset 記録したクエリを消去します
+func (c *Connector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = nil
+	c.args = nil
+}
+
+func (c *Connector) record(query string, args []driver.NamedValue) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = append(c.queries, query)
+	c.args = append(c.args, args)
+}
+
+type conn struct {
+	c *Connector
+}
+
+func (cn *conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("hyudbtest: prepare is not supported")
+}
+
+func (cn *conn) Close() error {
+	cn.c.mu.Lock()
+	defer cn.c.mu.Unlock()
+	cn.c.closed++
+	return nil
+}
+
+func (cn *conn) Begin() (driver.Tx, error) {
+	return cn, nil
+}
+
+func (cn *conn) Commit() error {
+	cn.c.record("COMMIT", nil)
+	return nil
+}
+
+func (cn *conn) Rollback() error {
+	cn.c.record("ROLLBACK", nil)
+	return nil
+}
+
+func (cn *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+
+	cn.c.record(query, args)
+
+	if cn.c.Exec == nil {
+		return Result{Affected: 1}, nil
+	}
+
+	return cn.c.Exec(query, args)
+}
+
+func (cn *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+
+	cn.c.record(query, args)
+
+	if cn.c.Query == nil {
+		return &Rows{}, nil
+	}
+
+	rows, err := cn.c.Query(query, args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// Rows SELECTの結果の行です
+type Rows struct {
+	Cols []string
+	Vals [][]driver.Value
+}
+
+// Columns driver.Rowsの実装です
+func (r *Rows) Columns() []string {
+	return r.Cols
+}
+
+// Close driver.Rowsの実装です
+func (r *Rows) Close() error {
+	return nil
+}
+
+// Next driver.Rowsの実装です
+func (r *Rows) Next(dest []driver.Value) error {
+
+	if len(r.Vals) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.Vals[0])
+	r.Vals = r.Vals[1:]
+
+	return nil
+}
+
+// Result LastInsertIdとRowsAffectedを指定した結果です
+type Result struct {
+	ID       int64
+	Affected int64
+}
+
+// LastInsertId driver.Resultの実装です
+func (r Result) LastInsertId() (int64, error) {
+	return r.ID, nil
+}
+
+// RowsAffected driver.Resultの実装です
+func (r Result) RowsAffected() (int64, error) {
+	return r.Affected, nil
+}
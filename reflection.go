@@ -1,6 +1,11 @@
 package hyutil
 
 import (
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"reflect"
 	"strconv"
@@ -9,6 +14,32 @@ import (
 
 //ObjFill はmap[string]stringを変換してmodelに展開します。
 func ObjFill(model interface{}, row map[string]string, isBbfil bool) {
+	ObjFillCheck(model, row, isBbfil)
+}
+
+//FillError は値を変換できなかったフィールドです。
+type FillError struct {
+	// Key rowのキー
+	Key string
+	// Field 構造体のフィールド名
+	Field string
+	Value string
+	// Type フィールドの型
+	Type string
+	Err  error
+}
+
+func (e *FillError) Error() string {
+	return e.Key + " '" + e.Value + "' を " + e.Type + " に変換できません：" + e.Err.Error()
+}
+
+func (e *FillError) Unwrap() error {
+	return e.Err
+}
+
+//ObjFillCheck はObjFillと同様に展開し、変換できなかったフィールドを返却します。
+//変換できなかったフィールドにはObjFillと同じくゼロ値が設定されます。
+func ObjFillCheck(model interface{}, row map[string]string, isBbfil bool) []*FillError {
 
 	if bf, ok := model.(BeforeFiller); ok {
 		bf.FillBefore()
@@ -24,9 +55,24 @@ func ObjFill(model interface{}, row map[string]string, isBbfil bool) {
 
 	if tp.Kind() != reflect.Struct {
 		log.Println("ObjFill:タイプ取得不正：" + tp.Kind().String())
-		return
+		return nil
+	}
+
+	var errs []*FillError
+
+	fillStruct(val, tp, row, isBbfil, []string{""}, &errs)
+
+	if af, ok := model.(AfterFiller); ok {
+		af.FillAfter()
 	}
 
+	return errs
+}
+
+// fillStruct 構造体のフィールドに展開します。prefixesはキーの前に付ける候補です
+// 埋め込み構造体は同じキーで、入れ子の構造体は "address.city" または "address_city" のキーで展開します
+func fillStruct(val reflect.Value, tp reflect.Type, row map[string]string, isBbfil bool, prefixes []string, errs *[]*FillError) {
+
 	for i := 0; i < tp.NumField(); i++ {
 
 		field := tp.Field(i)
@@ -45,40 +91,124 @@ func ObjFill(model interface{}, row map[string]string, isBbfil bool) {
 			colname = strings.ToLower(CamelToSnake(field.Name))
 		}
 
-		valStr, ok := row[colname]
+		key, valStr, ok := lookupKey(row, prefixes, colname)
 
 		if ok {
-			dest := val.FieldByName(field.Name)
-			convData(&dest, valStr)
+			dest := val.Field(i)
+			if err := convData(&dest, valStr); err != nil {
+				*errs = append(*errs, &FillError{
+					Key:   key,
+					Field: field.Name,
+					Value: valStr,
+					Type:  field.Type.String(),
+					Err:   err,
+				})
+			}
+			continue
+		}
+
+		if !IsNested(field.Type) || !(field.IsExported() || field.Anonymous) {
+			continue
 		}
 
+		if field.Anonymous {
+			fillStruct(val.Field(i), field.Type, row, isBbfil, prefixes, errs)
+			continue
+		}
+
+		nested := make([]string, 0, len(prefixes)*2)
+		for _, p := range prefixes {
+			nested = append(nested, p+colname+".", p+colname+"_")
+		}
+
+		fillStruct(val.Field(i), field.Type, row, isBbfil, nested, errs)
 	}
+}
 
-	if af, ok := model.(AfterFiller); ok {
-		af.FillAfter()
+// lookupKey 前に付ける候補の順にrowのキーを探します
+func lookupKey(row map[string]string, prefixes []string, colname string) (string, string, bool) {
+
+	for _, p := range prefixes {
+		if v, ok := row[p+colname]; ok {
+			return p + colname, v, true
+		}
+	}
+
+	return "", "", false
+}
+
+var (
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	valuerType        = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+//IsNested は埋め込みや入れ子としてフィールドごとに展開する構造体の型かを返却します。
+//DateTimeのように文字列やJSON、DBの値として表現できる構造体は1つの値として扱います。
+func IsNested(tp reflect.Type) bool {
+
+	if tp.Kind() != reflect.Struct {
+		return false
+	}
+
+	for _, it := range []reflect.Type{stringerType, textMarshalerType, jsonMarshalerType, valuerType} {
+		if tp.Implements(it) || reflect.PointerTo(tp).Implements(it) {
+			return false
+		}
 	}
 
+	return true
 }
 
-func convData(dest *reflect.Value, valStr string) {
+var (
+	errNotNumber   = errors.New("数値ではありません")
+	errOutOfRange  = errors.New("範囲外の数値です")
+	errNotBool     = errors.New("真偽値ではありません")
+	errNotDateTime = errors.New("日付ではありません")
+	errNoCase      = errors.New("対応していない型です")
+)
+
+func numError(err error) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return errOutOfRange
+	}
+	return errNotNumber
+}
+
+func convData(dest *reflect.Value, valStr string) error {
 
 	if !dest.CanSet() {
-		return
+		return nil
 	}
 
+	// 空文字とNULLはゼロ値として扱う
+	empty := valStr == "" || valStr == "NULL"
+
 	//フィールドのTypeによって文字列から変換
 	switch dest.Kind() {
 	case reflect.String:
 		dest.SetString(valStr)
 	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
-		i, _ := strconv.Atoi(valStr)
-		dest.SetInt(int64(i))
-	case reflect.Float32:
-		f, _ := strconv.ParseFloat(valStr, 32)
-		dest.SetFloat(float64(f))
-	case reflect.Float64:
-		f, _ := strconv.ParseFloat(valStr, 64)
-		dest.SetFloat(float64(f))
+		i, err := strconv.ParseInt(valStr, 10, dest.Type().Bits())
+		if err != nil {
+			dest.SetInt(0)
+			if !empty {
+				return numError(err)
+			}
+			return nil
+		}
+		dest.SetInt(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(valStr, dest.Type().Bits())
+		if err != nil {
+			dest.SetFloat(0)
+			if !empty {
+				return numError(err)
+			}
+			return nil
+		}
+		dest.SetFloat(f)
 	case reflect.Bool:
 		b := false
 		if valStr == "1" {
@@ -88,6 +218,9 @@ func convData(dest *reflect.Value, valStr string) {
 			b = true
 		}
 		dest.SetBool(b)
+		if !b && !empty && valStr != "0" && valStr != "false" {
+			return errNotBool
+		}
 	case reflect.Struct:
 		switch dest.Interface().(type) {
 		case DateTime:
@@ -96,15 +229,22 @@ func convData(dest *reflect.Value, valStr string) {
 				dt := DatetimeParse(valStr)
 				set := reflect.ValueOf(dt)
 				dest.Set(set)
+
+				if dt == DateTimeZero && !empty {
+					return errNotDateTime
+				}
 			}
 		default:
 			log.Println("no case " + dest.Kind().String())
+			return errNoCase
 		}
 
 	default:
 		log.Println("no case " + dest.Kind().String())
+		return errNoCase
 	}
 
+	return nil
 }
 
 //BeforeFiller Fill系メソッドで前処理を定義する
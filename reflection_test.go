@@ -0,0 +1,100 @@
+package hyutil_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gara-snake/hyutil"
+
+	"github.com/cheekybits/is"
+)
+
+type fillObj struct {
+	Name  string
+	Age   int32
+	Rate  float64
+	Valid bool
+	Date  hyutil.DateTime
+}
+
+func TestObjFillCheck(t *testing.T) {
+
+	is := is.New(t)
+
+	var obj fillObj
+
+	errs := hyutil.ObjFillCheck(&obj, map[string]string{
+		"name":  "テスト",
+		"age":   "20",
+		"rate":  "",
+		"valid": "NULL",
+		"date":  "2020-01-02",
+	}, false)
+	is.Equal(0, len(errs))
+	is.Equal("テスト", obj.Name)
+	is.Equal(int32(20), obj.Age)
+
+	errs = hyutil.ObjFillCheck(&obj, map[string]string{
+		"age":   "abc",
+		"rate":  "1.5.0",
+		"valid": "はい",
+		"date":  "2020年",
+	}, false)
+	is.Equal(4, len(errs))
+	is.Equal(int32(0), obj.Age)
+	is.Equal("age 'abc' を int32 に変換できません：数値ではありません", errs[0].Error())
+	is.Equal("Rate", errs[1].Field)
+	is.Equal("valid", errs[2].Key)
+	is.Equal("hyutil.DateTime", errs[3].Type)
+
+	errs = hyutil.ObjFillCheck(&obj, map[string]string{"age": "9999999999"}, false)
+	is.Equal("範囲外の数値です", errs[0].Err.Error())
+
+}
+
+type baseObj struct {
+	ID      int64
+	InsDate hyutil.DateTime
+}
+
+type addressObj struct {
+	Zip  string
+	City string
+}
+
+type nestedObj struct {
+	baseObj
+	Name    string
+	Address addressObj
+	Office  addressObj `json:"office"`
+}
+
+func TestObjFillNested(t *testing.T) {
+
+	is := is.New(t)
+
+	var obj nestedObj
+
+	errs := hyutil.ObjFillCheck(&obj, map[string]string{
+		"id":           "10",
+		"ins_date":     "2020-01-02",
+		"name":         "テスト",
+		"address.zip":  "100-0001",
+		"address_city": "千代田区",
+		"office_zip":   "abc",
+		"office.city":  "港区",
+	}, false)
+	is.Equal(0, len(errs))
+	is.Equal(int64(10), obj.ID)
+	is.Equal("2020/01/02", obj.InsDate.Format("2006/01/02"))
+	is.Equal(addressObj{Zip: "100-0001", City: "千代田区"}, obj.Address)
+	is.Equal(addressObj{Zip: "abc", City: "港区"}, obj.Office)
+
+	errs = hyutil.ObjFillCheck(&obj, map[string]string{"id": "x"}, false)
+	is.Equal(1, len(errs))
+	is.Equal("ID", errs[0].Field)
+
+	is.True(hyutil.IsNested(reflect.TypeOf(addressObj{})))
+	is.False(hyutil.IsNested(reflect.TypeOf(hyutil.DateTime{})))
+	is.False(hyutil.IsNested(reflect.TypeOf(&addressObj{})))
+}